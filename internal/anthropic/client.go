@@ -0,0 +1,209 @@
+package anthropic
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/catatsuy/bento/internal/apiclient"
+	"github.com/catatsuy/bento/internal/apierror"
+	"github.com/catatsuy/bento/internal/sse"
+)
+
+// AnthropicAPIURL is the endpoint of the Anthropic Messages API.
+var AnthropicAPIURL = "https://api.anthropic.com/v1/messages"
+
+// APIVersion is sent as the anthropic-version header.
+const APIVersion = "2023-06-01"
+
+// DefaultMaxTokens is used when Payload.MaxTokens is not set,
+// because the Messages API requires max_tokens.
+const DefaultMaxTokens = 8192
+
+// Client handles requests to the Anthropic API.
+type Client struct {
+	apiclient.Client
+	APIKey string
+}
+
+// Payload is the request body for the Messages API.
+type Payload struct {
+	Model     string    `json:"model"`
+	MaxTokens int       `json:"max_tokens"`
+	System    string    `json:"system,omitempty"`
+	Messages  []Message `json:"messages"`
+	Stream    bool      `json:"stream,omitempty"`
+}
+
+// Message represents a chat message.
+type Message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// Response is the API response from the Messages API.
+type Response struct {
+	ID         string    `json:"id"`
+	Type       string    `json:"type"`
+	Role       string    `json:"role"`
+	Model      string    `json:"model"`
+	Content    []Content `json:"content"`
+	StopReason string    `json:"stop_reason"`
+	Usage      Usage     `json:"usage"`
+}
+
+// Content is a content block in the response.
+type Content struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// Usage reports the number of tokens used by the request.
+type Usage struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+}
+
+// OutputText returns the concatenated text content blocks of the response.
+func (r *Response) OutputText() string {
+	var text string
+	for _, content := range r.Content {
+		if content.Type == "text" {
+			text += content.Text
+		}
+	}
+	return text
+}
+
+// NewClient creates a new Anthropic client.
+func NewClient(urlStr, apiKey string) (*Client, error) {
+	if urlStr == "" {
+		return nil, fmt.Errorf("anthropic client: missing url")
+	}
+	if apiKey == "" {
+		return nil, fmt.Errorf("anthropic client: missing api key")
+	}
+	parsedURL, err := url.ParseRequestURI(urlStr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse url %s: %w", urlStr, err)
+	}
+	return &Client{
+		Client: apiclient.New(parsedURL, "anthropic"),
+		APIKey: apiKey,
+	}, nil
+}
+
+// header returns the headers authenticating a request and selecting the version of the API.
+func (c *Client) header() http.Header {
+	h := http.Header{}
+	h.Set("x-api-key", c.APIKey)
+	h.Set("anthropic-version", APIVersion)
+	return h
+}
+
+// withMaxTokens returns param with DefaultMaxTokens if it does not set MaxTokens.
+func withMaxTokens(param *Payload) *Payload {
+	p := *param
+	if p.MaxTokens == 0 {
+		p.MaxTokens = DefaultMaxTokens
+	}
+	return &p
+}
+
+// Chat sends a request to the Messages API and returns the response.
+func (c *Client) Chat(ctx context.Context, param *Payload) (*Response, error) {
+	if len(param.Messages) == 0 || param.Messages[0].Content == "" {
+		return nil, fmt.Errorf("missing message content")
+	}
+
+	res, err := c.Post(ctx, c.header(), withMaxTokens(param))
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	response := &Response{}
+	err = json.NewDecoder(res.Body).Decode(response)
+	if err != nil {
+		return nil, fmt.Errorf("decode error: %w", err)
+	}
+
+	return response, nil
+}
+
+// StreamEvent is an event of the Messages API stream.
+type StreamEvent struct {
+	Type    string       `json:"type"`
+	Message *Response    `json:"message"`
+	Delta   *StreamDelta `json:"delta"`
+	Usage   *Usage       `json:"usage"`
+}
+
+// StreamDelta is the delta of a content_block_delta or message_delta event.
+type StreamDelta struct {
+	Type       string `json:"type"`
+	Text       string `json:"text"`
+	StopReason string `json:"stop_reason"`
+}
+
+// ChatStream sends a streaming request and calls fn with each text delta as it arrives.
+// It returns the usage reported by the message_start and message_delta events.
+func (c *Client) ChatStream(ctx context.Context, param *Payload, fn func(delta string) error) (*Usage, error) {
+	if len(param.Messages) == 0 || param.Messages[0].Content == "" {
+		return nil, fmt.Errorf("missing message content")
+	}
+
+	p := withMaxTokens(param)
+	p.Stream = true
+
+	res, err := c.PostStream(ctx, c.header(), p)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	usage := &Usage{}
+	reader := sse.NewReader(res.Body)
+	for {
+		ev, err := reader.Next()
+		if err == io.EOF {
+			// The response ends with message_stop, so the output is truncated.
+			return nil, sse.ErrIncomplete
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read stream error: %w", err)
+		}
+
+		streamEvent := &StreamEvent{}
+		if err := json.Unmarshal([]byte(ev.Data), streamEvent); err != nil {
+			return nil, fmt.Errorf("decode stream error: %w", err)
+		}
+
+		switch streamEvent.Type {
+		case "message_start":
+			if streamEvent.Message != nil {
+				usage.InputTokens = streamEvent.Message.Usage.InputTokens
+				usage.OutputTokens = streamEvent.Message.Usage.OutputTokens
+			}
+		case "content_block_delta":
+			if streamEvent.Delta == nil || streamEvent.Delta.Type != "text_delta" {
+				continue
+			}
+			if err := fn(streamEvent.Delta.Text); err != nil {
+				return nil, err
+			}
+		case "message_delta":
+			// The output tokens in message_delta are cumulative.
+			if streamEvent.Usage != nil {
+				usage.OutputTokens = streamEvent.Usage.OutputTokens
+			}
+		case "message_stop":
+			return usage, nil
+		case "error":
+			return nil, fmt.Errorf("stream error: %w", apierror.Parse("anthropic", []byte(ev.Data)))
+		}
+	}
+}
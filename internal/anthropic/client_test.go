@@ -0,0 +1,224 @@
+package anthropic_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	. "github.com/catatsuy/bento/internal/anthropic"
+	"github.com/catatsuy/bento/internal/sse"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestChat_Success(t *testing.T) {
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	param := &Payload{
+		Model:  "claude-haiku-4-5",
+		System: "You are a helpful assistant.",
+		Messages: []Message{
+			{
+				Role:    "user",
+				Content: "Hello. I am a student.",
+			},
+		},
+	}
+
+	token := "test-token"
+
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		// Check headers.
+		if r.Header.Get("Content-Type") != "application/json" {
+			t.Fatalf("Content-Type expected 'application/json', got %s", r.Header.Get("Content-Type"))
+		}
+		if r.Header.Get("x-api-key") != token {
+			t.Fatalf("x-api-key expected '%s', got %s", token, r.Header.Get("x-api-key"))
+		}
+		if r.Header.Get("anthropic-version") != APIVersion {
+			t.Fatalf("anthropic-version expected '%s', got %s", APIVersion, r.Header.Get("anthropic-version"))
+		}
+
+		// Check the request body.
+		bodyBytes, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer r.Body.Close()
+
+		actualPayload := &Payload{}
+		if err := json.Unmarshal(bodyBytes, actualPayload); err != nil {
+			t.Fatal(err)
+		}
+
+		expectedPayload := *param
+		expectedPayload.MaxTokens = DefaultMaxTokens
+		if diff := cmp.Diff(&expectedPayload, actualPayload); diff != "" {
+			t.Fatalf("payload mismatch (-expected +actual):\n%s", diff)
+		}
+
+		http.ServeFile(w, r, "testdata/messages_ok.json")
+	})
+
+	client, err := NewClient(server.URL, token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := client.Chat(t.Context(), param)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := &Response{
+		ID:    "msg_013Zva2CMHLNnXjNJJKqJ2EF",
+		Type:  "message",
+		Role:  "assistant",
+		Model: "claude-haiku-4-5",
+		Content: []Content{
+			{
+				Type: "text",
+				Text: "Hello there, how may I assist you today?",
+			},
+		},
+		StopReason: "end_turn",
+		Usage: Usage{
+			InputTokens:  12,
+			OutputTokens: 10,
+		},
+	}
+
+	if diff := cmp.Diff(expected, res); diff != "" {
+		t.Errorf("mismatch (-expected +actual):\n%s", diff)
+	}
+
+	if res.OutputText() != "Hello there, how may I assist you today?" {
+		t.Errorf("unexpected output text: %q", res.OutputText())
+	}
+}
+
+func TestChat_Fail(t *testing.T) {
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	param := &Payload{
+		Model: "claude-0",
+		Messages: []Message{
+			{
+				Role:    "user",
+				Content: "Hello. I am a student.",
+			},
+		},
+	}
+
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		http.ServeFile(w, r, "testdata/messages_fail.json")
+	})
+
+	client, err := NewClient(server.URL, "test-token")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = client.Chat(t.Context(), param)
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+	if !strings.Contains(err.Error(), "status code: 404") {
+		t.Fatalf("expected error to contain 'status code: 404', got %s", err.Error())
+	}
+}
+
+func TestChatStream(t *testing.T) {
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	param := &Payload{
+		Model: "claude-haiku-4-5",
+		Messages: []Message{
+			{
+				Role:    "user",
+				Content: "Hello. I am a student.",
+			},
+		},
+	}
+
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		actualPayload := &Payload{}
+		if err := json.NewDecoder(r.Body).Decode(actualPayload); err != nil {
+			t.Fatal(err)
+		}
+		if !actualPayload.Stream {
+			t.Fatal("expected stream to be true")
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		events := [][2]string{
+			{"message_start", `{"type":"message_start","message":{"id":"msg_1","usage":{"input_tokens":25,"output_tokens":1}}}`},
+			{"content_block_start", `{"type":"content_block_start","index":0}`},
+			{"ping", `{"type":"ping"}`},
+			{"content_block_delta", `{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Hello"}}`},
+			{"content_block_delta", `{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":" there"}}`},
+			{"content_block_stop", `{"type":"content_block_stop","index":0}`},
+			{"message_delta", `{"type":"message_delta","delta":{"stop_reason":"end_turn"},"usage":{"output_tokens":15}}`},
+			{"message_stop", `{"type":"message_stop"}`},
+		}
+		for _, ev := range events {
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", ev[0], ev[1])
+			w.(http.Flusher).Flush()
+		}
+	})
+
+	client, err := NewClient(server.URL, "test-token")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var b strings.Builder
+	usage, err := client.ChatStream(t.Context(), param, func(delta string) error {
+		b.WriteString(delta)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if b.String() != "Hello there" {
+		t.Errorf("expected %q, got %q", "Hello there", b.String())
+	}
+
+	expectedUsage := &Usage{InputTokens: 25, OutputTokens: 15}
+	if diff := cmp.Diff(expectedUsage, usage); diff != "" {
+		t.Errorf("usage mismatch (-expected +actual):\n%s", diff)
+	}
+}
+
+func TestChatStream_Incomplete(t *testing.T) {
+	// The connection is dropped before message_stop.
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"index\":0,\"delta\":{\"type\":\"text_delta\",\"text\":\"Hel\"}}\n\n")
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL, "test-token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	param := &Payload{Model: "claude-haiku-4-5", Messages: []Message{{Role: "user", Content: "Hello."}}}
+	_, err = client.ChatStream(t.Context(), param, func(delta string) error {
+		return nil
+	})
+	if !errors.Is(err, sse.ErrIncomplete) {
+		t.Errorf("expected %v, got %v", sse.ErrIncomplete, err)
+	}
+}
@@ -0,0 +1,88 @@
+// Package apiclient implements the HTTP plumbing shared by the API clients:
+// posting a JSON request with retries and turning an error response into an *apierror.APIError.
+package apiclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/catatsuy/bento/internal/apierror"
+	"github.com/catatsuy/bento/internal/retry"
+)
+
+// DefaultTimeout is the timeout of the requests that are not streamed.
+const DefaultTimeout = 30 * time.Second
+
+// Client posts requests to one endpoint of an API. The API clients embed it.
+type Client struct {
+	URL        *url.URL
+	HTTPClient *http.Client
+	// Provider names the backend in the errors of the API, such as "openai" or "openai-compatible".
+	Provider string
+
+	// Retry is the policy for retrying transient failures. If nil, requests are not retried.
+	Retry *retry.Policy
+}
+
+// New returns a Client posting to u with DefaultTimeout.
+func New(u *url.URL, provider string) Client {
+	return Client{
+		URL:        u,
+		HTTPClient: &http.Client{Timeout: DefaultTimeout},
+		Provider:   provider,
+	}
+}
+
+// Post sends param as JSON with header and returns the response if the status code is 200.
+// Otherwise the error of the API is returned as an *apierror.APIError.
+func (c *Client) Post(ctx context.Context, header http.Header, param any) (*http.Response, error) {
+	return c.post(ctx, c.HTTPClient, header, param)
+}
+
+// PostStream is Post for a streaming request. The timeout of HTTPClient is not applied,
+// because it would cut off a long response; use ctx to cancel the request.
+func (c *Client) PostStream(ctx context.Context, header http.Header, param any) (*http.Response, error) {
+	hc := *c.HTTPClient
+	hc.Timeout = 0
+	return c.post(ctx, &hc, header, param)
+}
+
+func (c *Client) post(ctx context.Context, hc *http.Client, header http.Header, param any) (*http.Response, error) {
+	b, err := json.Marshal(param)
+	if err != nil {
+		return nil, fmt.Errorf("marshal error: %w", err)
+	}
+
+	res, err := c.Retry.Do(ctx, func() (*http.Response, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.URL.String(), bytes.NewReader(b))
+		if err != nil {
+			return nil, err
+		}
+		for key, values := range header {
+			req.Header[key] = values
+		}
+		req.Header.Set("Content-Type", "application/json")
+
+		return hc.Do(req)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		defer res.Body.Close()
+		body, err := io.ReadAll(res.Body)
+		if err != nil {
+			return nil, fmt.Errorf("read body error: %w", err)
+		}
+		return nil, apierror.New(c.Provider, res, body)
+	}
+
+	return res, nil
+}
@@ -0,0 +1,111 @@
+package apiclient_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "github.com/catatsuy/bento/internal/apiclient"
+	"github.com/catatsuy/bento/internal/apierror"
+	"github.com/catatsuy/bento/internal/retry"
+)
+
+func newClient(t *testing.T, server *httptest.Server) Client {
+	t.Helper()
+
+	u, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return New(u, "test")
+}
+
+func TestPost(t *testing.T) {
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if attempts.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer key" {
+			t.Errorf("Authorization=%q, want %q", got, "Bearer key")
+		}
+		if got := r.Header.Get("Content-Type"); got != "application/json" {
+			t.Errorf("Content-Type=%q, want %q", got, "application/json")
+		}
+		var param map[string]string
+		if err := json.NewDecoder(r.Body).Decode(&param); err != nil || param["model"] != "m" {
+			t.Errorf("unexpected body %v: %v", param, err)
+		}
+		fmt.Fprint(w, "ok")
+	}))
+	defer server.Close()
+
+	c := newClient(t, server)
+	c.Retry = &retry.Policy{MaxRetries: 1, BaseDelay: time.Millisecond, MaxDelay: time.Second}
+	header := http.Header{}
+	header.Set("Authorization", "Bearer key")
+
+	res, err := c.Post(t.Context(), header, map[string]string{"model": "m"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	if b, _ := io.ReadAll(res.Body); string(b) != "ok" {
+		t.Errorf("body=%q, want %q", b, "ok")
+	}
+	if n := attempts.Load(); n != 2 {
+		t.Errorf("expected the failure to be retried once, got %d attempts", n)
+	}
+}
+
+func TestPost_Fail(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		fmt.Fprint(w, `{"error":{"message":"invalid key"}}`)
+	}))
+	defer server.Close()
+
+	c := newClient(t, server)
+	_, err := c.Post(t.Context(), nil, struct{}{})
+
+	var apiErr *apierror.APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("expected an *apierror.APIError, got %v", err)
+	}
+	if apiErr.Provider != "test" || apiErr.StatusCode != http.StatusUnauthorized {
+		t.Errorf("unexpected error %+v", apiErr)
+	}
+}
+
+func TestPostStream_NoTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		time.Sleep(50 * time.Millisecond)
+		fmt.Fprint(w, "done")
+	}))
+	defer server.Close()
+
+	c := newClient(t, server)
+	c.HTTPClient.Timeout = 10 * time.Millisecond
+
+	res, err := c.PostStream(t.Context(), nil, struct{}{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	if b, err := io.ReadAll(res.Body); err != nil || string(b) != "done" {
+		t.Errorf("body=%q, err=%v; want the whole body", b, err)
+	}
+	if c.HTTPClient.Timeout != 10*time.Millisecond {
+		t.Errorf("expected the timeout of the client to be kept, got %v", c.HTTPClient.Timeout)
+	}
+}
@@ -0,0 +1,196 @@
+// Package apierror defines the error returned by the API clients
+// when the API responds with an error.
+package apierror
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Category classifies an APIError by what the user can do about it.
+type Category int
+
+const (
+	CategoryUnknown Category = iota
+	CategoryAuth
+	CategoryRateLimit
+	CategoryContextLength
+	CategoryContentFilter
+	CategoryNotFound
+	CategoryInvalidRequest
+	CategoryServer
+)
+
+// String returns the name of the category.
+func (c Category) String() string {
+	switch c {
+	case CategoryAuth:
+		return "auth"
+	case CategoryRateLimit:
+		return "rate limit"
+	case CategoryContextLength:
+		return "context length"
+	case CategoryContentFilter:
+		return "content filter"
+	case CategoryNotFound:
+		return "not found"
+	case CategoryInvalidRequest:
+		return "invalid request"
+	case CategoryServer:
+		return "server"
+	}
+	return "unknown"
+}
+
+// APIError is an error response from an API.
+// Use errors.As to retrieve it from the errors returned by the clients.
+type APIError struct {
+	Provider   string
+	StatusCode int
+	// Type is the error type reported by the provider, e.g. invalid_request_error.
+	Type string
+	// Code is the error code reported by the provider, e.g. model_not_found.
+	Code      string
+	Message   string
+	RequestID string
+	// Body is the raw response body.
+	Body []byte
+}
+
+// requestIDHeaders are the headers in which the providers return the request ID.
+var requestIDHeaders = []string{"x-request-id", "request-id", "x-goog-request-id"}
+
+// New creates an APIError from a response with a non-200 status code and its body.
+func New(provider string, res *http.Response, body []byte) *APIError {
+	e := Parse(provider, body)
+	e.StatusCode = res.StatusCode
+	for _, h := range requestIDHeaders {
+		if v := res.Header.Get(h); v != "" {
+			e.RequestID = v
+			break
+		}
+	}
+	return e
+}
+
+// Parse creates an APIError from an error JSON body such as
+//
+//	{"error": {"message": "...", "type": "...", "code": "..."}}
+//
+// It also accepts the error object without the envelope, as sent in stream events,
+// and the array form returned by Gemini.
+// If the body cannot be parsed, only Body is set.
+func Parse(provider string, body []byte) *APIError {
+	e := &APIError{Provider: provider, Body: body}
+
+	type errorObject struct {
+		Message string          `json:"message"`
+		Type    string          `json:"type"`
+		Code    json.RawMessage `json:"code"`
+		Status  string          `json:"status"`
+	}
+	type envelope struct {
+		errorObject
+		Error     *errorObject `json:"error"`
+		RequestID string       `json:"request_id"`
+	}
+
+	var env envelope
+	if err := json.Unmarshal(body, &env); err != nil {
+		var envs []envelope
+		if err := json.Unmarshal(body, &envs); err != nil || len(envs) == 0 {
+			return e
+		}
+		env = envs[0]
+	}
+
+	obj := env.errorObject
+	if env.Error != nil {
+		obj = *env.Error
+	}
+
+	e.Message = obj.Message
+	e.Type = obj.Type
+	if e.Type == "" || e.Type == "error" {
+		// Gemini reports the gRPC status instead of a type.
+		e.Type = obj.Status
+	}
+	e.Code = rawToString(obj.Code)
+	e.RequestID = env.RequestID
+	return e
+}
+
+// rawToString returns a JSON string or number as a string.
+func rawToString(raw json.RawMessage) string {
+	if len(raw) == 0 || string(raw) == "null" {
+		return ""
+	}
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return s
+	}
+	return string(raw)
+}
+
+// Error returns the status code followed by the details reported by the provider.
+func (e *APIError) Error() string {
+	var parts []string
+	if e.StatusCode != 0 {
+		parts = append(parts, fmt.Sprintf("status code: %d", e.StatusCode))
+	}
+	if e.Message == "" {
+		parts = append(parts, fmt.Sprintf("body: %s", e.Body))
+	} else {
+		if e.Type != "" {
+			parts = append(parts, "type: "+e.Type)
+		}
+		if e.Code != "" {
+			parts = append(parts, "code: "+e.Code)
+		}
+		parts = append(parts, "message: "+e.Message)
+	}
+	if e.RequestID != "" {
+		parts = append(parts, "request id: "+e.RequestID)
+	}
+	return strings.Join(parts, "; ")
+}
+
+// Category classifies the error from the status code and the provider error code.
+func (e *APIError) Category() Category {
+	detail := strings.ToLower(e.Type + " " + e.Code + " " + e.Message)
+
+	switch {
+	case strings.Contains(detail, "context_length_exceeded"),
+		strings.Contains(detail, "maximum context length"),
+		strings.Contains(detail, "prompt is too long"),
+		strings.Contains(detail, "exceeds the maximum number of tokens"),
+		e.StatusCode == http.StatusRequestEntityTooLarge:
+		return CategoryContextLength
+	case strings.Contains(detail, "content_filter"),
+		strings.Contains(detail, "content_policy_violation"),
+		strings.Contains(detail, "refusal"):
+		return CategoryContentFilter
+	case strings.Contains(detail, "insufficient_quota"),
+		strings.Contains(detail, "rate_limit"),
+		strings.Contains(detail, "resource_exhausted"),
+		e.StatusCode == http.StatusTooManyRequests:
+		return CategoryRateLimit
+	case e.StatusCode == http.StatusUnauthorized,
+		e.StatusCode == http.StatusForbidden,
+		strings.Contains(detail, "invalid_api_key"),
+		strings.Contains(detail, "authentication_error"),
+		strings.Contains(detail, "permission_error"):
+		return CategoryAuth
+	case e.StatusCode == http.StatusNotFound:
+		return CategoryNotFound
+	case e.StatusCode >= 500,
+		strings.Contains(detail, "overloaded"),
+		strings.Contains(detail, "server_error"):
+		return CategoryServer
+	case e.StatusCode >= 400:
+		return CategoryInvalidRequest
+	}
+	return CategoryUnknown
+}
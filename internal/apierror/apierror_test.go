@@ -0,0 +1,121 @@
+package apierror_test
+
+import (
+	"net/http"
+	"testing"
+
+	. "github.com/catatsuy/bento/internal/apierror"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider string
+		status   int
+		header   http.Header
+		body     string
+		expected *APIError
+		category Category
+	}{
+		{
+			name:     "openai",
+			provider: "openai",
+			status:   http.StatusNotFound,
+			header:   http.Header{"X-Request-Id": {"req_123"}},
+			body:     `{"error":{"message":"The model ` + "`gpt-3`" + ` does not exist","type":"invalid_request_error","param":null,"code":"model_not_found"}}`,
+			expected: &APIError{
+				Provider:   "openai",
+				StatusCode: http.StatusNotFound,
+				Type:       "invalid_request_error",
+				Code:       "model_not_found",
+				Message:    "The model `gpt-3` does not exist",
+				RequestID:  "req_123",
+			},
+			category: CategoryNotFound,
+		},
+		{
+			name:     "openai context length",
+			provider: "openai",
+			status:   http.StatusBadRequest,
+			body:     `{"error":{"message":"Your input exceeds the context window of this model.","type":"invalid_request_error","code":"context_length_exceeded"}}`,
+			expected: &APIError{
+				Provider:   "openai",
+				StatusCode: http.StatusBadRequest,
+				Type:       "invalid_request_error",
+				Code:       "context_length_exceeded",
+				Message:    "Your input exceeds the context window of this model.",
+			},
+			category: CategoryContextLength,
+		},
+		{
+			name:     "gemini",
+			provider: "gemini",
+			status:   http.StatusTooManyRequests,
+			body:     `[{"error":{"code":429,"message":"Resource has been exhausted","status":"RESOURCE_EXHAUSTED"}}]`,
+			expected: &APIError{
+				Provider:   "gemini",
+				StatusCode: http.StatusTooManyRequests,
+				Type:       "RESOURCE_EXHAUSTED",
+				Code:       "429",
+				Message:    "Resource has been exhausted",
+			},
+			category: CategoryRateLimit,
+		},
+		{
+			name:     "anthropic",
+			provider: "anthropic",
+			status:   http.StatusUnauthorized,
+			header:   http.Header{"Request-Id": {"req_456"}},
+			body:     `{"type":"error","error":{"type":"authentication_error","message":"invalid x-api-key"}}`,
+			expected: &APIError{
+				Provider:   "anthropic",
+				StatusCode: http.StatusUnauthorized,
+				Type:       "authentication_error",
+				Message:    "invalid x-api-key",
+				RequestID:  "req_456",
+			},
+			category: CategoryAuth,
+		},
+		{
+			name:     "not json",
+			provider: "openai",
+			status:   http.StatusBadGateway,
+			body:     `Bad Gateway`,
+			expected: &APIError{
+				Provider:   "openai",
+				StatusCode: http.StatusBadGateway,
+			},
+			category: CategoryServer,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := &http.Response{StatusCode: tt.status, Header: tt.header}
+			actual := New(tt.provider, res, []byte(tt.body))
+
+			if diff := cmp.Diff(tt.expected, actual, cmpopts.IgnoreFields(APIError{}, "Body")); diff != "" {
+				t.Errorf("mismatch (-expected +actual):\n%s", diff)
+			}
+			if actual.Category() != tt.category {
+				t.Errorf("Category()=%v, want %v", actual.Category(), tt.category)
+			}
+		})
+	}
+}
+
+func TestAPIError_Error(t *testing.T) {
+	e := &APIError{StatusCode: 404, Type: "invalid_request_error", Code: "model_not_found", Message: "not found", RequestID: "req_1"}
+	expected := "status code: 404; type: invalid_request_error; code: model_not_found; message: not found; request id: req_1"
+	if e.Error() != expected {
+		t.Errorf("Error()=%q, want %q", e.Error(), expected)
+	}
+
+	e = &APIError{StatusCode: 502, Body: []byte("Bad Gateway")}
+	expected = "status code: 502; body: Bad Gateway"
+	if e.Error() != expected {
+		t.Errorf("Error()=%q, want %q", e.Error(), expected)
+	}
+}
@@ -0,0 +1,153 @@
+// Package cache stores responses of the models on disk, keyed by the hash of the request,
+// so that the same request is not paid for again.
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Store is a directory of cached values. Each value is a file named by its key.
+// It is safe for concurrent use, also by several processes.
+type Store struct {
+	dir string
+}
+
+// New returns a Store in dir. The directory is created when the first value is stored.
+func New(dir string) *Store {
+	return &Store{dir: dir}
+}
+
+// Key returns the key of the request made of parts. The parts are length-prefixed
+// before they are hashed, so that different splits of the same text give different keys.
+func Key(parts ...string) string {
+	h := sha256.New()
+	for _, p := range parts {
+		h.Write([]byte(strconv.Itoa(len(p))))
+		h.Write([]byte{0})
+		h.Write([]byte(p))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// path returns the path of the value of key. The values are spread over
+// subdirectories named by the first two characters of the keys.
+func (s *Store) path(key string) string {
+	return filepath.Join(s.dir, key[:2], key)
+}
+
+// Get returns the value of key. If maxAge is positive, values stored earlier than maxAge ago are ignored.
+func (s *Store) Get(key string, maxAge time.Duration) (string, bool) {
+	p := s.path(key)
+	if maxAge > 0 {
+		fi, err := os.Stat(p)
+		if err != nil || time.Since(fi.ModTime()) > maxAge {
+			return "", false
+		}
+	}
+	b, err := os.ReadFile(p)
+	if err != nil {
+		return "", false
+	}
+	return string(b), true
+}
+
+// Put stores value as the value of key. The file is written atomically,
+// so a concurrent Get sees either the old or the new value.
+func (s *Store) Put(key, value string) error {
+	p := s.path(key)
+	if err := os.MkdirAll(filepath.Dir(p), 0o700); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(filepath.Dir(p), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.WriteString(value); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), p)
+}
+
+// Touch marks the value of key as used now, so that Prune keeps it.
+func (s *Store) Touch(key string) error {
+	now := time.Now()
+	return os.Chtimes(s.path(key), now, now)
+}
+
+// PruneResult is the result of Prune.
+type PruneResult struct {
+	Files int
+	Bytes int64
+}
+
+// Prune removes the values stored or used earlier than olderThan ago, or all of them if olderThan is 0,
+// and the temporary files left by interrupted writes. Only the files laid out by Put are removed:
+// a file named by a key in the subdirectory named by its first two characters. Anything else in
+// the directory is left alone, and symbolic links are not followed.
+func (s *Store) Prune(olderThan time.Duration) (PruneResult, error) {
+	var res PruneResult
+	subdirs, err := os.ReadDir(s.dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return res, nil
+	}
+	if err != nil {
+		return res, err
+	}
+	for _, subdir := range subdirs {
+		if !subdir.IsDir() || len(subdir.Name()) != 2 || !isHex(subdir.Name()) {
+			continue
+		}
+		dir := filepath.Join(s.dir, subdir.Name())
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return res, err
+		}
+		for _, e := range entries {
+			if !e.Type().IsRegular() {
+				continue
+			}
+			name := e.Name()
+			isValue := len(name) == sha256.Size*2 && isHex(name) && strings.HasPrefix(name, subdir.Name())
+			if !isValue && !strings.HasPrefix(name, ".tmp-") {
+				continue
+			}
+			fi, err := e.Info()
+			if err != nil {
+				return res, err
+			}
+			if olderThan > 0 && time.Since(fi.ModTime()) <= olderThan {
+				continue
+			}
+			if err := os.Remove(filepath.Join(dir, name)); err != nil {
+				return res, err
+			}
+			res.Files++
+			res.Bytes += fi.Size()
+		}
+	}
+	return res, nil
+}
+
+// isHex reports whether s is made of lowercase hexadecimal digits, like the keys.
+func isHex(s string) bool {
+	for _, r := range s {
+		if !('0' <= r && r <= '9' || 'a' <= r && r <= 'f') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,172 @@
+package cache_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/catatsuy/bento/internal/cache"
+)
+
+func TestStore(t *testing.T) {
+	s := New(filepath.Join(t.TempDir(), "cache"))
+	key := Key("hello", "ja")
+
+	if _, ok := s.Get(key, 0); ok {
+		t.Fatal("expected a miss before Put")
+	}
+	if err := s.Put(key, "こんにちは"); err != nil {
+		t.Fatal(err)
+	}
+	v, ok := s.Get(key, 0)
+	if !ok || v != "こんにちは" {
+		t.Errorf("expected a hit with %q, got %q, %v", "こんにちは", v, ok)
+	}
+
+	if err := s.Put(key, "やあ"); err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := s.Get(key, time.Hour); v != "やあ" {
+		t.Errorf("expected the value to be replaced, got %q", v)
+	}
+}
+
+func TestStore_maxAge(t *testing.T) {
+	dir := t.TempDir()
+	s := New(dir)
+	key := Key("hello")
+	if err := s.Put(key, "value"); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-2 * time.Hour)
+	if err := os.Chtimes(filepath.Join(dir, key[:2], key), old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := s.Get(key, time.Hour); ok {
+		t.Error("expected a miss for an expired value")
+	}
+	if _, ok := s.Get(key, 0); !ok {
+		t.Error("expected a hit without a maximum age")
+	}
+}
+
+func TestKey(t *testing.T) {
+	if Key("ab", "c") == Key("a", "bc") {
+		t.Error("expected different keys for different parts")
+	}
+	if Key("a", "b") != Key("a", "b") {
+		t.Error("expected the same key for the same parts")
+	}
+}
+
+func TestStore_Prune(t *testing.T) {
+	dir := t.TempDir()
+	s := New(dir)
+
+	oldKey, newKey, touchedKey := Key("old"), Key("new"), Key("touched")
+	for _, key := range []string{oldKey, newKey, touchedKey} {
+		if err := s.Put(key, "value"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	old := time.Now().Add(-48 * time.Hour)
+	for _, key := range []string{oldKey, touchedKey} {
+		if err := os.Chtimes(filepath.Join(dir, key[:2], key), old, old); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.Touch(touchedKey); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := s.Prune(24 * time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Files != 1 || res.Bytes != 5 {
+		t.Errorf("expected 1 file of 5 bytes to be removed, got %+v", res)
+	}
+	if _, ok := s.Get(oldKey, 0); ok {
+		t.Error("expected the old value to be removed")
+	}
+	for _, key := range []string{newKey, touchedKey} {
+		if _, ok := s.Get(key, 0); !ok {
+			t.Errorf("expected %s to be kept", key)
+		}
+	}
+
+	res, err = s.Prune(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Files != 2 {
+		t.Errorf("expected all the files to be removed, got %+v", res)
+	}
+
+	// A missing directory has nothing to prune.
+	if _, err := New(filepath.Join(dir, "missing")).Prune(0); err != nil {
+		t.Errorf("expected no error for a missing directory, got %v", err)
+	}
+}
+
+func TestStore_PruneLayout(t *testing.T) {
+	dir := t.TempDir()
+	s := New(dir)
+
+	key := Key("value")
+	if err := s.Put(key, "value"); err != nil {
+		t.Fatal(err)
+	}
+	tmp := filepath.Join(dir, key[:2], ".tmp-123")
+	outside := t.TempDir()
+	// A key in the subdirectory of another key.
+	misplaced := strings.Repeat("0", 64)
+	if key[:2] == "00" {
+		misplaced = strings.Repeat("1", 64)
+	}
+	kept := []string{
+		filepath.Join(dir, "config.toml"),
+		filepath.Join(dir, key[:2], "notes.txt"),
+		filepath.Join(dir, key[:2], misplaced),
+		filepath.Join(dir, "git", key),
+		filepath.Join(dir, key[:2], "sub", key),
+		filepath.Join(outside, key),
+	}
+	for _, name := range append(kept, tmp) {
+		if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(name, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// A link to another directory is not followed.
+	link := "ff"
+	if key[:2] == link {
+		link = "00"
+	}
+	if err := os.Symlink(outside, filepath.Join(dir, link)); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := s.Prune(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Files != 2 {
+		t.Errorf("expected the value and the temporary file to be removed, got %+v", res)
+	}
+	for _, name := range []string{filepath.Join(dir, key[:2], key), tmp} {
+		if _, err := os.Stat(name); !os.IsNotExist(err) {
+			t.Errorf("expected %s to be removed, got %v", name, err)
+		}
+	}
+	for _, name := range kept {
+		if _, err := os.Stat(name); err != nil {
+			t.Errorf("expected %s to be kept, got %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+// Package catalog reads and writes the i18n resource files translated by
+// "bento translate-catalog": JSON and YAML locale files and gettext .po files.
+package catalog
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Formats of the catalogs.
+const (
+	FormatJSON = "json"
+	FormatYAML = "yaml"
+	FormatPO   = "po"
+)
+
+// Message is a translatable string of a catalog.
+type Message struct {
+	// ID identifies the message in the catalog, such as the path of a key or the msgid of a .po entry.
+	ID   string
+	Text string
+	// Translation is the translated text, or an empty string if the message is not translated.
+	Translation string
+}
+
+// Catalog is a parsed catalog file.
+type Catalog interface {
+	// Messages returns the translatable messages in the order of the file.
+	Messages() []*Message
+	// Write writes the catalog with the translations of the messages.
+	// Messages without a translation keep their text in JSON and YAML files.
+	Write(w io.Writer) error
+}
+
+// FormatOf returns the format of the catalog file at path from its extension.
+func FormatOf(path string) (string, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return FormatJSON, nil
+	case ".yaml", ".yml":
+		return FormatYAML, nil
+	case ".po", ".pot":
+		return FormatPO, nil
+	}
+	return "", fmt.Errorf("unknown catalog format of %s. Use a .json, .yaml, .yml, .po or .pot file or give -format", path)
+}
+
+// Parse parses data in format.
+func Parse(format string, data []byte) (Catalog, error) {
+	switch format {
+	case FormatJSON:
+		return parseJSON(data)
+	case FormatYAML:
+		return parseYAML(data)
+	case FormatPO:
+		return parsePO(data)
+	}
+	return nil, fmt.Errorf("unknown catalog format %q. Use json, yaml or po", format)
+}
+
+// Translations returns the translations in an existing catalog of the target language by message ID.
+// The values of JSON and YAML files are their translations, while .po files have them in msgstr.
+func Translations(format string, data []byte) (map[string]string, error) {
+	c, err := Parse(format, data)
+	if err != nil {
+		return nil, err
+	}
+	translations := make(map[string]string)
+	for _, m := range c.Messages() {
+		t := m.Translation
+		if format != FormatPO {
+			t = m.Text
+		}
+		if t != "" {
+			translations[m.ID] = t
+		}
+	}
+	return translations, nil
+}
+
+// Placeholders matches the placeholders of the common i18n libraries that must not be translated:
+// printf verbs such as %s, %d and %1$s, Python's %(name)s, {name}, {{name}}, ${name} and HTML tags.
+var Placeholders = regexp.MustCompile(`%(?:\d+\$)?[-+0#]*\d*(?:\.\d+)?[sdfiuxXoeEgGcqvpt%@]` +
+	`|%\([A-Za-z_]\w*\)[sdfr]` +
+	`|\{\{[^{}]*\}\}` +
+	`|\$?\{[A-Za-z0-9_.]*\}` +
+	`|</?[A-Za-z][^<>]*>`)
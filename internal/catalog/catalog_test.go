@@ -0,0 +1,321 @@
+package catalog_test
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	. "github.com/catatsuy/bento/internal/catalog"
+	"github.com/google/go-cmp/cmp"
+)
+
+// parseFile parses the catalog in testdata and returns it with its messages by ID.
+func parseFile(t *testing.T, name string) (Catalog, []byte, map[string]*Message) {
+	t.Helper()
+
+	data, err := os.ReadFile("testdata/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	format, err := FormatOf(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := Parse(format, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	messages := make(map[string]*Message)
+	for _, m := range c.Messages() {
+		messages[m.ID] = m
+	}
+	return c, data, messages
+}
+
+func write(t *testing.T, c Catalog) string {
+	t.Helper()
+
+	var b bytes.Buffer
+	if err := c.Write(&b); err != nil {
+		t.Fatal(err)
+	}
+	return b.String()
+}
+
+func TestJSON(t *testing.T) {
+	c, data, messages := parseFile(t, "en.json")
+
+	var texts []string
+	for _, m := range c.Messages() {
+		texts = append(texts, m.ID+"="+m.Text)
+	}
+	expected := []string{
+		"/app/title=Bento",
+		"/app/greeting=Hello, {name}!",
+		"/app/items=You have %d items",
+		"/app/html=Click <b>Save</b> & continue",
+		"/menu/0=Open",
+		"/menu/1=Close",
+		"/empty=",
+	}
+	if diff := cmp.Diff(expected, texts); diff != "" {
+		t.Errorf("messages mismatch (-expected +actual):\n%s", diff)
+	}
+
+	// Without translations, the file is written as it was.
+	if out := write(t, c); out != string(data) {
+		t.Errorf("expected the file unchanged, got:\n%s", out)
+	}
+
+	messages["/app/greeting"].Translation = "こんにちは、{name}さん！"
+	messages["/menu/1"].Translation = "閉じる"
+	expectedJSON := `{
+    "app": {
+        "title": "Bento",
+        "greeting": "こんにちは、{name}さん！",
+        "items": "You have %d items",
+        "html": "Click <b>Save</b> & continue"
+    },
+    "menu": [
+        "Open",
+        "閉じる"
+    ],
+    "count": 3,
+    "enabled": true,
+    "empty": "",
+    "missing": null
+}
+`
+	if diff := cmp.Diff(expectedJSON, write(t, c)); diff != "" {
+		t.Errorf("JSON mismatch (-expected +actual):\n%s", diff)
+	}
+}
+
+func TestYAML(t *testing.T) {
+	c, _, messages := parseFile(t, "en.yaml")
+
+	var ids []string
+	for _, m := range c.Messages() {
+		ids = append(ids, m.ID)
+	}
+	expected := []string{"/en/app/title", "/en/app/greeting", "/en/menu/0", "/en/menu/1"}
+	if diff := cmp.Diff(expected, ids); diff != "" {
+		t.Errorf("messages mismatch (-expected +actual):\n%s", diff)
+	}
+
+	messages["/en/app/title"].Translation = "弁当: ランチ"
+	messages["/en/app/greeting"].Translation = "こんにちは、%{name}さん！"
+	messages["/en/menu/0"].Translation = "開く"
+
+	expectedYAML := `# Application strings
+en:
+  app:
+    title: '弁当: ランチ'
+    greeting: "こんにちは、%{name}さん！"
+  menu:
+    - 開く
+    - Close
+  count: 3
+`
+	if diff := cmp.Diff(expectedYAML, write(t, c)); diff != "" {
+		t.Errorf("YAML mismatch (-expected +actual):\n%s", diff)
+	}
+}
+
+func TestPO(t *testing.T) {
+	c, data, messages := parseFile(t, "ja.po")
+
+	var got []Message
+	for _, m := range c.Messages() {
+		got = append(got, *m)
+	}
+	expected := []Message{
+		{ID: "Hello, %s!", Text: "Hello, %s!"},
+		{ID: "Open", Text: "Open", Translation: "開く"},
+		{ID: "menu\x04Open", Text: "Open"},
+		// Japanese has a single plural form, which translates the plural text.
+		{ID: "%d file[0]", Text: "%d files"},
+		{ID: "Line one\nLine two", Text: "Line one\nLine two"},
+	}
+	if diff := cmp.Diff(expected, got); diff != "" {
+		t.Errorf("messages mismatch (-expected +actual):\n%s", diff)
+	}
+
+	if out := write(t, c); out != string(data) {
+		t.Errorf("expected the file unchanged, got:\n%s", out)
+	}
+
+	messages["Hello, %s!"].Translation = "こんにちは、%s！"
+	messages["menu\x04Open"].Translation = "開く"
+	messages["%d file[0]"].Translation = "%d 個のファイル"
+	messages["Line one\nLine two"].Translation = "一行目\n二行目"
+
+	expectedPO := `# Japanese translations for bento.
+msgid ""
+msgstr ""
+"Language: ja\n"
+"Content-Type: text/plain; charset=UTF-8\n"
+"Plural-Forms: nplurals=1; plural=0;\n"
+
+#: main.go:10
+msgid "Hello, %s!"
+msgstr "こんにちは、%s！"
+
+#: main.go:11
+msgid "Open"
+msgstr "開く"
+
+msgctxt "menu"
+msgid "Open"
+msgstr "開く"
+
+#, c-format
+msgid "%d file"
+msgid_plural "%d files"
+msgstr[0] "%d 個のファイル"
+
+msgid ""
+"Line one\n"
+"Line two"
+msgstr ""
+"一行目\n"
+"二行目"
+
+#~ msgid "Obsolete"
+#~ msgstr "廃止"
+`
+	if diff := cmp.Diff(expectedPO, write(t, c)); diff != "" {
+		t.Errorf("PO mismatch (-expected +actual):\n%s", diff)
+	}
+}
+
+func TestPO_pluralForms(t *testing.T) {
+	data := []byte(`msgid ""
+msgstr "Plural-Forms: nplurals=3; plural=(n%10==1 && n%100!=11 ? 0 : 1);\n"
+
+msgid "%d file"
+msgid_plural "%d files"
+msgstr[0] ""
+msgstr[1] ""
+`)
+	c, err := Parse(FormatPO, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var texts []string
+	for _, m := range c.Messages() {
+		texts = append(texts, m.ID+"="+m.Text)
+	}
+	expected := []string{"%d file[0]=%d file", "%d file[1]=%d files", "%d file[2]=%d files"}
+	if diff := cmp.Diff(expected, texts); diff != "" {
+		t.Errorf("messages mismatch (-expected +actual):\n%s", diff)
+	}
+}
+
+func TestPO_fuzzy(t *testing.T) {
+	data := `msgid ""
+msgstr ""
+"Plural-Forms: nplurals=1; plural=0;\n"
+
+#: main.go:10
+#, fuzzy, c-format
+#| msgid "Hello!"
+msgid "Hello, %s!"
+msgstr "こんにちは！"
+
+#, fuzzy
+msgid "Close"
+msgstr "開く"
+`
+	c, err := Parse(FormatPO, []byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The guessed translations of fuzzy entries are translated again.
+	var got []Message
+	for _, m := range c.Messages() {
+		got = append(got, *m)
+	}
+	expected := []Message{{ID: "Hello, %s!", Text: "Hello, %s!"}, {ID: "Close", Text: "Close"}}
+	if diff := cmp.Diff(expected, got); diff != "" {
+		t.Errorf("messages mismatch (-expected +actual):\n%s", diff)
+	}
+
+	// An entry translated again is no longer fuzzy, and the others are kept as they are.
+	c.Messages()[0].Translation = "こんにちは、%s！"
+	expectedPO := `msgid ""
+msgstr ""
+"Plural-Forms: nplurals=1; plural=0;\n"
+
+#: main.go:10
+#, c-format
+msgid "Hello, %s!"
+msgstr "こんにちは、%s！"
+
+#, fuzzy
+msgid "Close"
+msgstr "開く"
+`
+	if diff := cmp.Diff(expectedPO, write(t, c)); diff != "" {
+		t.Errorf("PO mismatch (-expected +actual):\n%s", diff)
+	}
+}
+
+func TestPO_noPluralForms(t *testing.T) {
+	plural := "msgid \"%d file\"\nmsgid_plural \"%d files\"\nmsgstr[0] \"\"\nmsgstr[1] \"\"\n"
+	tests := map[string]string{
+		"no header":  plural,
+		"pot header": "msgid \"\"\nmsgstr \"Plural-Forms: nplurals=INTEGER; plural=EXPRESSION;\\n\"\n\n" + plural,
+	}
+	for name, data := range tests {
+		_, err := Parse(FormatPO, []byte(data))
+		if err == nil || !strings.Contains(err.Error(), "Plural-Forms") {
+			t.Errorf("%s: expected an error asking for Plural-Forms, got %v", name, err)
+		}
+	}
+
+	// Without plural messages, the header is not needed.
+	if _, err := Parse(FormatPO, []byte("msgid \"Open\"\nmsgstr \"\"\n")); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestTranslations(t *testing.T) {
+	translations, err := Translations(FormatJSON, []byte(`{"app": {"title": "弁当", "empty": ""}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(map[string]string{"/app/title": "弁当"}, translations); diff != "" {
+		t.Errorf("translations mismatch (-expected +actual):\n%s", diff)
+	}
+
+	translations, err = Translations(FormatPO, []byte("msgid \"Open\"\nmsgstr \"開く\"\n\nmsgid \"Close\"\nmsgstr \"\"\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(map[string]string{"Open": "開く"}, translations); diff != "" {
+		t.Errorf("translations mismatch (-expected +actual):\n%s", diff)
+	}
+}
+
+func TestPlaceholders(t *testing.T) {
+	tests := map[string][]string{
+		"You have %d items":          {"%d"},
+		"%1$s and %2$s":              {"%1$s", "%2$s"},
+		"Hello, %(name)s":            {"%(name)s"},
+		"Hello, {name} and {{user}}": {"{name}", "{{user}}"},
+		"Hi %{name}, ${count}":       {"{name}", "${count}"},
+		"100% done, %%":              {"%%"},
+		"Click <b>Save</b>":          {"<b>", "</b>"},
+	}
+	for text, expected := range tests {
+		got := Placeholders.FindAllString(text, -1)
+		if diff := cmp.Diff(expected, got); diff != "" {
+			t.Errorf("%q: placeholders mismatch (-expected +actual):\n%s", text, diff)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package catalog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// jsonNode is a value of a JSON document. Objects keep the order of their keys.
+type jsonNode struct {
+	// kind is '{' for objects, '[' for arrays, '"' for strings and 0 for the other values.
+	kind   byte
+	keys   []string
+	values []*jsonNode
+	msg    *Message
+	// raw is the JSON text of numbers, booleans and null.
+	raw string
+}
+
+// jsonCatalog is a JSON locale file. All the strings are messages, identified by their JSON pointer.
+// Plural keys, such as "key_one" and "key_other" of i18next, and ICU plural messages are plain
+// messages: the plural forms of the target language are not added.
+type jsonCatalog struct {
+	root     *jsonNode
+	messages []*Message
+	indent   string
+}
+
+func parseJSON(data []byte) (*jsonCatalog, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	c := &jsonCatalog{indent: detectIndent(data)}
+	root, err := c.parseValue(dec, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse JSON: %w", err)
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, fmt.Errorf("failed to parse JSON: unexpected data after the top-level value")
+	}
+	c.root = root
+	return c, nil
+}
+
+func (c *jsonCatalog) parseValue(dec *json.Decoder, path string) (*jsonNode, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch v := tok.(type) {
+	case json.Delim:
+		n := &jsonNode{kind: byte(v)}
+		for i := 0; dec.More(); i++ {
+			key := strconv.Itoa(i)
+			if v == '{' {
+				kt, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				key = kt.(string)
+				n.keys = append(n.keys, key)
+			}
+			child, err := c.parseValue(dec, path+"/"+escapePointer(key))
+			if err != nil {
+				return nil, err
+			}
+			n.values = append(n.values, child)
+		}
+		// The closing delimiter.
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+		return n, nil
+	case string:
+		m := &Message{ID: path, Text: v}
+		c.messages = append(c.messages, m)
+		return &jsonNode{kind: '"', msg: m}, nil
+	case json.Number:
+		return &jsonNode{raw: v.String()}, nil
+	case bool:
+		return &jsonNode{raw: strconv.FormatBool(v)}, nil
+	default:
+		return &jsonNode{raw: "null"}, nil
+	}
+}
+
+// escapePointer escapes a key for a JSON pointer.
+func escapePointer(key string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(key)
+}
+
+// detectIndent returns the indentation of the first indented line of data, or two spaces if there is none.
+func detectIndent(data []byte) string {
+	for line := range strings.SplitSeq(string(data), "\n") {
+		trimmed := strings.TrimLeft(line, " \t")
+		if trimmed != "" && len(trimmed) < len(line) {
+			return line[:len(line)-len(trimmed)]
+		}
+	}
+	return "  "
+}
+
+func (c *jsonCatalog) Messages() []*Message {
+	return c.messages
+}
+
+func (c *jsonCatalog) Write(w io.Writer) error {
+	var b bytes.Buffer
+	if err := c.write(&b, c.root, 0); err != nil {
+		return err
+	}
+	b.WriteString("\n")
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+func (c *jsonCatalog) write(b *bytes.Buffer, n *jsonNode, depth int) error {
+	switch n.kind {
+	case '{', '[':
+		closing := "}"
+		if n.kind == '[' {
+			closing = "]"
+		}
+		b.WriteByte(n.kind)
+		if len(n.values) == 0 {
+			b.WriteString(closing)
+			return nil
+		}
+		b.WriteString("\n")
+		for i, v := range n.values {
+			b.WriteString(strings.Repeat(c.indent, depth+1))
+			if n.kind == '{' {
+				if err := writeJSONString(b, n.keys[i]); err != nil {
+					return err
+				}
+				b.WriteString(": ")
+			}
+			if err := c.write(b, v, depth+1); err != nil {
+				return err
+			}
+			if i < len(n.values)-1 {
+				b.WriteString(",")
+			}
+			b.WriteString("\n")
+		}
+		b.WriteString(strings.Repeat(c.indent, depth))
+		b.WriteString(closing)
+	case '"':
+		text := n.msg.Text
+		if n.msg.Translation != "" {
+			text = n.msg.Translation
+		}
+		return writeJSONString(b, text)
+	default:
+		b.WriteString(n.raw)
+	}
+	return nil
+}
+
+// writeJSONString writes s as a JSON string without escaping HTML characters.
+func writeJSONString(b *bytes.Buffer, s string) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(s); err != nil {
+		return err
+	}
+	b.Write(bytes.TrimSuffix(buf.Bytes(), []byte("\n")))
+	return nil
+}
@@ -0,0 +1,331 @@
+package catalog
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// poEntry is an entry of a .po file.
+type poEntry struct {
+	// lines are the lines of the entry, written as is unless the entry is translated.
+	lines []string
+	// msgstrStart is the index of the first msgstr line in lines, or -1 if the entry has no msgid.
+	msgstrStart int
+	// trailing are the blank lines after the entry.
+	trailing []string
+
+	msgctxt     *string
+	msgid       string
+	msgidPlural *string
+	msgstr      []string
+	// fuzzy is set by the fuzzy flag, which marks a translation to be checked, such as one
+	// that msgmerge guessed from a similar msgid. It is translated again.
+	fuzzy bool
+
+	messages []*Message
+}
+
+// key returns the key of the entry: the msgid prefixed by the msgctxt, if any.
+func (e *poEntry) key() string {
+	if e.msgctxt != nil {
+		return *e.msgctxt + "\x04" + e.msgid
+	}
+	return e.msgid
+}
+
+// poCatalog is a gettext .po file. Each msgstr of the entries is a message,
+// so a plural entry has one message per plural form of the target language.
+type poCatalog struct {
+	entries  []*poEntry
+	messages []*Message
+}
+
+// nplurals matches the number of plural forms in the Plural-Forms header.
+var nplurals = regexp.MustCompile(`nplurals\s*=\s*(\d+)`)
+
+func parsePO(data []byte) (*poCatalog, error) {
+	c := &poCatalog{}
+	lines := strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	var e *poEntry
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			if e == nil {
+				e = &poEntry{msgstrStart: -1}
+				c.entries = append(c.entries, e)
+			}
+			e.trailing = append(e.trailing, line)
+			continue
+		}
+		if e == nil || len(e.trailing) > 0 {
+			e = &poEntry{msgstrStart: -1}
+			c.entries = append(c.entries, e)
+		}
+		e.lines = append(e.lines, line)
+	}
+
+	// The number of plural forms depends on the language, so it is not guessed.
+	forms := 0
+	for i, e := range c.entries {
+		if err := e.parse(); err != nil {
+			return nil, fmt.Errorf("failed to parse the entry %d of the .po file: %w", i+1, err)
+		}
+		// The header has the number of plural forms of the language.
+		if e.msgstrStart >= 0 && e.key() == "" && len(e.msgstr) > 0 {
+			if m := nplurals.FindStringSubmatch(e.msgstr[0]); m != nil {
+				forms, _ = strconv.Atoi(m[1])
+			}
+		}
+	}
+
+	for _, e := range c.entries {
+		if e.msgstrStart < 0 || e.key() == "" {
+			continue
+		}
+		if e.msgidPlural != nil && forms == 0 {
+			return nil, fmt.Errorf(`the plural message %q needs the number of plural forms of the target language. Add it to the header, such as "Plural-Forms: nplurals=1; plural=0;\n" for Japanese or "Plural-Forms: nplurals=2; plural=(n != 1);\n" for English`, e.msgid)
+		}
+		if e.msgidPlural == nil {
+			var translation string
+			if !e.fuzzy {
+				translation = e.msgstr[0]
+			}
+			e.messages = []*Message{{ID: e.key(), Text: e.msgid, Translation: translation}}
+		} else {
+			n := max(forms, len(e.msgstr))
+			for i := range n {
+				// Languages with a single form use the plural text, such as "%d files".
+				text := *e.msgidPlural
+				if i == 0 && n > 1 {
+					text = e.msgid
+				}
+				var translation string
+				if i < len(e.msgstr) && !e.fuzzy {
+					translation = e.msgstr[i]
+				}
+				e.messages = append(e.messages, &Message{ID: e.key() + "[" + strconv.Itoa(i) + "]", Text: text, Translation: translation})
+			}
+		}
+		c.messages = append(c.messages, e.messages...)
+	}
+	return c, nil
+}
+
+// parse parses the keywords of the entry. Obsolete entries and comments are kept as they are.
+func (e *poEntry) parse() error {
+	var current *string
+	for i, line := range e.lines {
+		if strings.HasPrefix(line, "#,") && slices.Contains(poFlags(line), "fuzzy") {
+			e.fuzzy = true
+		}
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if strings.HasPrefix(line, `"`) {
+			if current == nil {
+				return fmt.Errorf("unexpected string: %s", line)
+			}
+			s, err := unquotePO(line)
+			if err != nil {
+				return err
+			}
+			*current += s
+			continue
+		}
+
+		keyword, value, ok := strings.Cut(line, " ")
+		if !ok {
+			return fmt.Errorf("invalid line: %s", line)
+		}
+		s, err := unquotePO(strings.TrimSpace(value))
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case keyword == "msgctxt":
+			e.msgctxt = &s
+			current = e.msgctxt
+		case keyword == "msgid":
+			e.msgid = s
+			current = &e.msgid
+		case keyword == "msgid_plural":
+			e.msgidPlural = &s
+			current = e.msgidPlural
+		case keyword == "msgstr" || strings.HasPrefix(keyword, "msgstr["):
+			if e.msgstrStart < 0 {
+				e.msgstrStart = i
+			}
+			e.msgstr = append(e.msgstr, s)
+			current = &e.msgstr[len(e.msgstr)-1]
+		default:
+			return fmt.Errorf("unknown keyword: %s", keyword)
+		}
+	}
+	if e.msgstrStart >= 0 {
+		for _, line := range e.lines[e.msgstrStart:] {
+			if !strings.HasPrefix(line, "msgstr") && !strings.HasPrefix(line, `"`) {
+				return fmt.Errorf("unexpected line after msgstr: %s", line)
+			}
+		}
+	}
+	return nil
+}
+
+func (c *poCatalog) Messages() []*Message {
+	return c.messages
+}
+
+func (c *poCatalog) Write(w io.Writer) error {
+	var b strings.Builder
+	for _, e := range c.entries {
+		lines := e.lines
+		if e.translated() {
+			lines = lines[:e.msgstrStart:e.msgstrStart]
+			if e.fuzzy {
+				lines = withoutFuzzy(lines)
+			}
+			lines = append(lines, e.msgstrLines()...)
+		}
+		for _, line := range lines {
+			b.WriteString(line)
+			b.WriteString("\n")
+		}
+		for _, line := range e.trailing {
+			b.WriteString(line)
+			b.WriteString("\n")
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// translated reports whether a message of the entry has a new translation.
+// A fuzzy entry keeps its translations until all of its messages are translated again.
+func (e *poEntry) translated() bool {
+	if e.fuzzy {
+		for _, m := range e.messages {
+			if m.Translation == "" {
+				return false
+			}
+		}
+		return len(e.messages) > 0
+	}
+	for i, m := range e.messages {
+		var msgstr string
+		if i < len(e.msgstr) {
+			msgstr = e.msgstr[i]
+		}
+		if m.Translation != msgstr {
+			return true
+		}
+	}
+	return false
+}
+
+// msgstrLines returns the msgstr lines of the translations of the messages.
+func (e *poEntry) msgstrLines() []string {
+	if e.msgidPlural == nil {
+		return quotePO("msgstr", e.messages[0].Translation)
+	}
+	var lines []string
+	for i, m := range e.messages {
+		lines = append(lines, quotePO("msgstr["+strconv.Itoa(i)+"]", m.Translation)...)
+	}
+	return lines
+}
+
+// poFlags returns the flags of a "#," comment line.
+func poFlags(line string) []string {
+	var flags []string
+	for flag := range strings.SplitSeq(strings.TrimPrefix(line, "#,"), ",") {
+		if flag = strings.TrimSpace(flag); flag != "" {
+			flags = append(flags, flag)
+		}
+	}
+	return flags
+}
+
+// withoutFuzzy returns the comment lines of an entry translated again without the fuzzy flag
+// and the previous msgid ("#|") that msgmerge adds to fuzzy entries, like the gettext tools do.
+func withoutFuzzy(lines []string) []string {
+	var kept []string
+	for _, line := range lines {
+		switch {
+		case strings.HasPrefix(line, "#|"):
+			continue
+		case strings.HasPrefix(line, "#,"):
+			flags := slices.DeleteFunc(poFlags(line), func(flag string) bool { return flag == "fuzzy" })
+			if len(flags) == 0 {
+				continue
+			}
+			line = "#, " + strings.Join(flags, ", ")
+		}
+		kept = append(kept, line)
+	}
+	return kept
+}
+
+// quotePO returns the lines of keyword with the value s, split after newlines like the gettext tools.
+func quotePO(keyword, s string) []string {
+	if !strings.Contains(strings.TrimSuffix(s, "\n"), "\n") {
+		return []string{keyword + " " + escapePO(s)}
+	}
+	lines := []string{keyword + ` ""`}
+	for part := range strings.SplitAfterSeq(s, "\n") {
+		if part != "" {
+			lines = append(lines, escapePO(part))
+		}
+	}
+	return lines
+}
+
+// escapePO quotes s as a C string.
+func escapePO(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\t", `\t`, "\r", `\r`)
+	return `"` + r.Replace(s) + `"`
+}
+
+// unquotePO unquotes a C string.
+func unquotePO(s string) (string, error) {
+	if len(s) < 2 || s[0] != '"' || s[len(s)-1] != '"' {
+		return "", fmt.Errorf("invalid string: %s", s)
+	}
+	s = s[1 : len(s)-1]
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 == len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 'n':
+			b.WriteByte('\n')
+		case 't':
+			b.WriteByte('\t')
+		case 'r':
+			b.WriteByte('\r')
+		case 'a':
+			b.WriteByte('\a')
+		case 'b':
+			b.WriteByte('\b')
+		case 'f':
+			b.WriteByte('\f')
+		case 'v':
+			b.WriteByte('\v')
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String(), nil
+}
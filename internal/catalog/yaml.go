@@ -0,0 +1,81 @@
+package catalog
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// yamlCatalog is a YAML locale file. All the string scalars are messages, identified
+// like JSON pointers. Comments and the order of the keys are kept.
+type yamlCatalog struct {
+	doc      yaml.Node
+	nodes    []*yaml.Node
+	messages []*Message
+	indent   int
+}
+
+func parseYAML(data []byte) (*yamlCatalog, error) {
+	c := &yamlCatalog{indent: 2}
+	if err := yaml.Unmarshal(data, &c.doc); err != nil {
+		return nil, fmt.Errorf("failed to parse YAML: %w", err)
+	}
+	if indent := detectIndent(data); strings.Trim(indent, " ") == "" {
+		c.indent = len(indent)
+	}
+	c.walk(&c.doc, "")
+	return c, nil
+}
+
+func (c *yamlCatalog) walk(n *yaml.Node, path string) {
+	switch n.Kind {
+	case yaml.DocumentNode:
+		for _, child := range n.Content {
+			c.walk(child, path)
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			c.walk(n.Content[i+1], path+"/"+escapePointer(n.Content[i].Value))
+		}
+	case yaml.SequenceNode:
+		for i, child := range n.Content {
+			c.walk(child, path+"/"+strconv.Itoa(i))
+		}
+	case yaml.ScalarNode:
+		if n.ShortTag() == "!!str" {
+			c.nodes = append(c.nodes, n)
+			c.messages = append(c.messages, &Message{ID: path, Text: n.Value})
+		}
+	}
+}
+
+func (c *yamlCatalog) Messages() []*Message {
+	return c.messages
+}
+
+func (c *yamlCatalog) Write(w io.Writer) error {
+	if c.doc.Kind == 0 {
+		return nil
+	}
+	for i, m := range c.messages {
+		if m.Translation != "" {
+			c.nodes[i].Value = m.Translation
+		}
+	}
+
+	var b bytes.Buffer
+	enc := yaml.NewEncoder(&b)
+	enc.SetIndent(c.indent)
+	if err := enc.Encode(&c.doc); err != nil {
+		return err
+	}
+	if err := enc.Close(); err != nil {
+		return err
+	}
+	_, err := w.Write(b.Bytes())
+	return err
+}
@@ -0,0 +1,189 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+var (
+	// invalidRefChars matches the characters git does not allow in ref names, and white space.
+	invalidRefChars = regexp.MustCompile(`[\x00-\x20\x7f~^:?*\[\\]+`)
+	// dots matches the runs of dots, since ".." is not allowed.
+	dots = regexp.MustCompile(`\.{2,}`)
+	// dashes matches the runs of dashes left by the replaced characters.
+	dashes = regexp.MustCompile(`-{2,}`)
+)
+
+// sanitizeBranchName turns a suggested branch name into a valid branch name
+// following the rules of "git check-ref-format --branch".
+func sanitizeBranchName(suggestion string) (string, error) {
+	// Models sometimes quote the name or add an explanation after it.
+	name := strings.TrimSpace(suggestion)
+	if line, _, ok := strings.Cut(name, "\n"); ok {
+		name = strings.TrimSpace(line)
+	}
+	name = strings.Trim(name, "`'\"")
+
+	name = invalidRefChars.ReplaceAllString(name, "-")
+	name = dots.ReplaceAllString(name, ".")
+	name = strings.ReplaceAll(name, "@{", "-")
+
+	var components []string
+	for c := range strings.SplitSeq(name, "/") {
+		// A component cannot begin with a dot or end with ".lock".
+		for {
+			c = strings.Trim(c, ".-")
+			trimmed, ok := strings.CutSuffix(c, ".lock")
+			if !ok {
+				break
+			}
+			c = trimmed
+		}
+		c = dashes.ReplaceAllString(c, "-")
+		if c != "" {
+			components = append(components, c)
+		}
+	}
+	name = strings.Join(components, "/")
+
+	if name == "" || name == "@" {
+		return "", fmt.Errorf("the suggestion %q cannot be made into a branch name", strings.TrimSpace(suggestion))
+	}
+	return name, nil
+}
+
+// commitMessageOf returns the commit message of a suggestion, removing the code block models sometimes add.
+func commitMessageOf(suggestion string) string {
+	msg := strings.TrimSpace(suggestion)
+	if strings.HasPrefix(msg, "```") && strings.HasSuffix(msg, "```") {
+		msg = strings.TrimSuffix(msg, "```")
+		// The first line is the opening fence with an optional language.
+		if _, rest, ok := strings.Cut(msg, "\n"); ok {
+			msg = rest
+		}
+		msg = strings.TrimSpace(msg)
+	}
+	return msg
+}
+
+// apply creates the suggested branch or makes a commit with the suggested message.
+// If terminal is not nil, the user is asked to confirm, edit or regenerate the suggestion first.
+// generate requests a new suggestion and writes it to the output stream.
+func (c *CLI) apply(ctx context.Context, commit bool, suggestion string, generate func(ctx context.Context) (string, error), terminal io.Reader) int {
+	var in *bufio.Reader
+	if terminal != nil {
+		in = bufio.NewReader(terminal)
+	}
+
+	for {
+		// value is the commit message or the branch name. It is empty if the suggestion cannot be used.
+		var value string
+		if commit {
+			value = commitMessageOf(suggestion)
+			if value == "" {
+				fmt.Fprintf(c.errStream, "Error: The suggested commit message is empty.\n")
+			}
+		} else {
+			name, err := c.branchName(ctx, suggestion)
+			if err != nil {
+				fmt.Fprintf(c.errStream, "Error: %v\n", err)
+			}
+			value = name
+		}
+
+		if in == nil {
+			switch {
+			case value == "":
+				return ExitCodeFail
+			case commit:
+				return c.gitApply(ctx, terminal, "commit", "-m", value)
+			default:
+				return c.gitApply(ctx, terminal, "switch", "-c", value)
+			}
+		}
+
+		switch {
+		case value == "":
+			fmt.Fprintf(c.errStream, "[e]dit, [r]egenerate, [n]o: ")
+		case commit:
+			fmt.Fprintf(c.errStream, "Commit with this message? [y]es, [e]dit, [r]egenerate, [n]o: ")
+		default:
+			fmt.Fprintf(c.errStream, "Create the branch %s? [y]es, [e]dit, [r]egenerate, [n]o: ", value)
+		}
+		answer, err := readAnswer(in)
+		if err != nil {
+			fmt.Fprintf(c.errStream, "\nNot applied.\n")
+			return ExitCodeOK
+		}
+
+		switch strings.ToLower(answer) {
+		case "y", "yes":
+			if value == "" {
+				continue
+			}
+			if commit {
+				return c.gitApply(ctx, terminal, "commit", "-m", value)
+			}
+			return c.gitApply(ctx, terminal, "switch", "-c", value)
+		case "e", "edit":
+			if commit {
+				// git opens the editor with the suggested message.
+				return c.gitApply(ctx, terminal, "commit", "-e", "-m", value)
+			}
+			fmt.Fprintf(c.errStream, "Branch name: ")
+			name, err := readAnswer(in)
+			if err != nil {
+				fmt.Fprintf(c.errStream, "\nNot applied.\n")
+				return ExitCodeOK
+			}
+			if name != "" {
+				suggestion = name
+			}
+		case "r", "regenerate":
+			// A regenerated suggestion must not come from the response cache.
+			s, err := generate(withoutCache(ctx))
+			if err != nil {
+				return c.requestError(err)
+			}
+			suggestion = s
+		case "n", "no":
+			fmt.Fprintf(c.errStream, "Not applied.\n")
+			return ExitCodeOK
+		}
+	}
+}
+
+// branchName sanitizes the suggested branch name and checks it with git.
+func (c *CLI) branchName(ctx context.Context, suggestion string) (string, error) {
+	name, err := sanitizeBranchName(suggestion)
+	if err != nil {
+		return "", err
+	}
+	if _, err := gitOutput(ctx, "check-ref-format", "--branch", name); err != nil {
+		return "", fmt.Errorf("%q is not a valid branch name: %w", name, err)
+	}
+	return name, nil
+}
+
+// gitApply runs git with args, connected to the terminal, if any, so that git can open the editor.
+func (c *CLI) gitApply(ctx context.Context, terminal io.Reader, args ...string) int {
+	if err := gitRun(ctx, terminal, c.errStream, c.errStream, args...); err != nil {
+		fmt.Fprintf(c.errStream, "Error: %v\n", err)
+		return ExitCodeFail
+	}
+	return ExitCodeOK
+}
+
+// readAnswer reads a line of the answer to a question. It returns io.EOF if there is no more input.
+func readAnswer(in *bufio.Reader) (string, error) {
+	line, err := in.ReadString('\n')
+	if err != nil && (!errors.Is(err, io.EOF) || line == "") {
+		return "", io.EOF
+	}
+	return strings.TrimSpace(line), nil
+}
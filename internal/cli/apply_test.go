@@ -0,0 +1,154 @@
+package cli_test
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/catatsuy/bento/internal/cli"
+)
+
+func TestSanitizeBranchName(t *testing.T) {
+	tests := []struct {
+		suggestion string
+		want       string
+	}{
+		{"feature/add-login", "feature/add-login"},
+		{"`fix/typo-in-readme`\n", "fix/typo-in-readme"},
+		{"Add login page", "Add-login-page"},
+		{"feature/add login: oauth?\nThis branch adds a login page.", "feature/add-login-oauth"},
+		{"fix..double~dot^", "fix.double-dot"},
+		{"/.hidden//branch.lock/", "hidden/branch"},
+		{"refs@{1}", "refs-1}"},
+		{"-leading-dash-", "leading-dash"},
+		{"feature/ユーザー登録", "feature/ユーザー登録"},
+	}
+	for _, tt := range tests {
+		got, err := SanitizeBranchName(tt.suggestion)
+		if err != nil {
+			t.Errorf("%q: unexpected error %v", tt.suggestion, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%q: got %q, want %q", tt.suggestion, got, tt.want)
+		}
+	}
+
+	for _, suggestion := range []string{"", "...", "@", "//"} {
+		if _, err := SanitizeBranchName(suggestion); err == nil {
+			t.Errorf("%q: expected an error", suggestion)
+		}
+	}
+}
+
+// runApply runs bento -apply with answers on the terminal and the suggestions returned in order.
+func runApply(t *testing.T, answers string, suggestions []string, args ...string) (string, string, int) {
+	t.Helper()
+
+	return runBento(t, newMockModel(suggestions...).translator(), env{stdin: answers, terminal: true}, append([]string{"-apply"}, args...)...)
+}
+
+func TestRun_applyBranch(t *testing.T) {
+	setupRepo(t)
+	writeFile(t, "README.md", "# bento\n\nLogin.\n")
+
+	_, errOut, status := runApply(t, "y\n", []string{"feature/add login"}, "-branch")
+	if status != ExitCodeOK {
+		t.Fatalf("ExitStatus=%d, want %d: %s", status, ExitCodeOK, errOut)
+	}
+	if !strings.Contains(errOut, "Create the branch feature/add-login?") {
+		t.Errorf("expected a confirmation, got %q", errOut)
+	}
+	if branch := strings.TrimSpace(git(t, "branch", "--show-current")); branch != "feature/add-login" {
+		t.Errorf("expected to be on feature/add-login, got %q", branch)
+	}
+}
+
+func TestRun_applyBranchEditAndRegenerate(t *testing.T) {
+	setupRepo(t)
+	writeFile(t, "README.md", "# bento\n\nLogin.\n")
+
+	// The answer is asked again for an unknown answer, a regenerated suggestion and an edited name.
+	out, errOut, status := runApply(t, "maybe\nr\ne\nmy branch\ny\n", []string{"first", "second"}, "-branch")
+	if status != ExitCodeOK {
+		t.Fatalf("ExitStatus=%d, want %d: %s", status, ExitCodeOK, errOut)
+	}
+	if out != "first\nsecond\n" {
+		t.Errorf("expected the suggestions to be written, got %q", out)
+	}
+	if branch := strings.TrimSpace(git(t, "branch", "--show-current")); branch != "my-branch" {
+		t.Errorf("expected to be on my-branch, got %q", branch)
+	}
+}
+
+func TestRun_applyNo(t *testing.T) {
+	setupRepo(t)
+	writeFile(t, "README.md", "# bento\n\nLogin.\n")
+
+	for _, answers := range []string{"n\n", ""} {
+		_, errOut, status := runApply(t, answers, []string{"feature/login"}, "-branch")
+		if status != ExitCodeOK {
+			t.Fatalf("ExitStatus=%d, want %d: %s", status, ExitCodeOK, errOut)
+		}
+		if !strings.Contains(errOut, "Not applied.") {
+			t.Errorf("expected nothing to be applied, got %q", errOut)
+		}
+		if branch := strings.TrimSpace(git(t, "branch", "--show-current")); branch != "main" {
+			t.Errorf("expected to stay on main, got %q", branch)
+		}
+	}
+}
+
+func TestRun_applyCommit(t *testing.T) {
+	setupRepo(t)
+	writeFile(t, "README.md", "# bento\n\nLogin.\n")
+	git(t, "add", "README.md")
+
+	_, errOut, status := runApply(t, "y\n", []string{"```\nAdd login to README\n```"}, "-commit")
+	if status != ExitCodeOK {
+		t.Fatalf("ExitStatus=%d, want %d: %s", status, ExitCodeOK, errOut)
+	}
+	if msg := git(t, "log", "-1", "--format=%B"); strings.TrimSpace(msg) != "Add login to README" {
+		t.Errorf("unexpected commit message %q", msg)
+	}
+}
+
+func TestRun_applyCommitEdit(t *testing.T) {
+	setupRepo(t)
+	writeFile(t, "README.md", "# bento\n\nLogin.\n")
+	git(t, "add", "README.md")
+	// The editor prefixes the message, like a user editing it.
+	t.Setenv("GIT_EDITOR", "sed -i.bak -e '1s/^/Edited: /'")
+
+	_, errOut, status := runApply(t, "e\n", []string{"Add login to README"}, "-commit")
+	if status != ExitCodeOK {
+		t.Fatalf("ExitStatus=%d, want %d: %s", status, ExitCodeOK, errOut)
+	}
+	if msg := git(t, "log", "-1", "--format=%B"); strings.TrimSpace(msg) != "Edited: Add login to README" {
+		t.Errorf("unexpected commit message %q", msg)
+	}
+}
+
+func TestRun_applyWithoutTerminal(t *testing.T) {
+	setupRepo(t)
+	writeFile(t, "README.md", "# bento\n\nLogin.\n")
+	git(t, "add", "README.md")
+
+	// The diff is piped in, so the commit is made without a question.
+	tr := newMockModel("Add login to README").translator()
+	if _, errOut := mustRunBento(t, tr, env{stdin: git(t, "diff", "--staged")}, "-commit", "-apply"); strings.Contains(errOut, "[y]es") {
+		t.Errorf("expected no question, got %q", errOut)
+	}
+	if msg := git(t, "log", "-1", "--format=%B"); strings.TrimSpace(msg) != "Add login to README" {
+		t.Errorf("unexpected commit message %q", msg)
+	}
+}
+
+func TestRun_applyInvalid(t *testing.T) {
+	_, errOut, status := runApply(t, "", []string{"ok"}, "-review", "-file", "testdata/test.txt")
+	if status != ExitCodeFail {
+		t.Errorf("ExitStatus=%d, want %d", status, ExitCodeFail)
+	}
+	if !strings.Contains(errOut, "can only be used with '-branch' or '-commit'") {
+		t.Errorf("unexpected error %q", errOut)
+	}
+}
@@ -0,0 +1,126 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// batchInstruction is added to the system prompt when short texts are translated in batches.
+const batchInstruction = "The input is a JSON object. Translate its values and keep the keys and the placeholders such as ⟦0⟧ unchanged. Respond only with a JSON object with the same keys."
+
+// batchItem is a text translated in a batch.
+type batchItem struct {
+	id   string
+	text string
+}
+
+// translateBatches translates many short texts, such as the messages of a catalog, with few requests.
+// The items are packed into JSON objects of at most opts.limit, measured on the texts, and requested
+// like the chunks of multi mode. The translations are returned by item ID; an item missing from a
+// response has no translation.
+func (c *CLI) translateBatches(ctx context.Context, systemPrompt, prompt, useModel string, opts multiOptions, items []batchItem) (map[string]string, error) {
+	measure := utf8.RuneCountInString
+	if opts.chunker != nil {
+		measure = opts.chunker.measure
+	}
+
+	var (
+		batches [][]batchItem
+		batch   []batchItem
+		size    int
+	)
+	for _, item := range items {
+		n := measure(item.text)
+		if len(batch) > 0 && size+n > opts.limit {
+			batches = append(batches, batch)
+			batch, size = nil, 0
+		}
+		batch = append(batch, item)
+		size += n
+	}
+	if len(batch) > 0 {
+		batches = append(batches, batch)
+	}
+
+	if systemPrompt != "" {
+		systemPrompt += "\n\n"
+	}
+	systemPrompt += batchInstruction
+
+	translations := make(map[string]string, len(items))
+	produce := func(send func(chunk string) error) error {
+		for _, batch := range batches {
+			input, err := encodeBatch(batch)
+			if err != nil {
+				return err
+			}
+			if err := send(input); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	// next is the index of the batch of the next response.
+	next := 0
+	consume := func(text string) error {
+		batch := batches[next]
+		next++
+		values, err := decodeBatch(text)
+		if err != nil {
+			return fmt.Errorf("the response is not a JSON object: %w", err)
+		}
+		for i, item := range batch {
+			if v, ok := values[strconv.Itoa(i+1)]; ok {
+				translations[item.id] = v
+			}
+		}
+		return nil
+	}
+
+	if err := c.requestChunks(ctx, systemPrompt, prompt, useModel, opts, produce, consume); err != nil {
+		return nil, err
+	}
+	return translations, nil
+}
+
+// encodeBatch returns the JSON object sent for batch. The keys are the positions of the items
+// from 1, so that the model sees short keys in the order of the texts.
+func encodeBatch(batch []batchItem) (string, error) {
+	var b strings.Builder
+	b.WriteString("{\n")
+	for i, item := range batch {
+		var buf bytes.Buffer
+		enc := json.NewEncoder(&buf)
+		enc.SetEscapeHTML(false)
+		if err := enc.Encode(item.text); err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&b, "  %q: %s", strconv.Itoa(i+1), bytes.TrimSuffix(buf.Bytes(), []byte("\n")))
+		if i < len(batch)-1 {
+			b.WriteString(",")
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("}")
+	return b.String(), nil
+}
+
+// decodeBatch parses the JSON object of a response. Models often wrap it in a code block,
+// so the text outside the outermost braces is ignored.
+func decodeBatch(text string) (map[string]string, error) {
+	start := strings.Index(text, "{")
+	end := strings.LastIndex(text, "}")
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("no JSON object found")
+	}
+	var values map[string]string
+	if err := json.Unmarshal([]byte(text[start:end+1]), &values); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
@@ -0,0 +1,128 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/catatsuy/bento/internal/cache"
+)
+
+// DefaultCacheTTL is the default time the responses cached with -cache are used.
+const DefaultCacheTTL = 24 * time.Hour
+
+// cacheTranslator returns the cached response to a request made before instead of requesting it again.
+// It wraps the backend, so the requests are cached with the prompts actually sent.
+type cacheTranslator struct {
+	Translator
+
+	store   *cache.Store
+	backend string
+	// ttl is how long a response is used. 0 means forever.
+	ttl time.Duration
+
+	// verbose receives a line for each hit and miss. If nil, nothing is written.
+	verbose io.Writer
+}
+
+// newCacheTranslator wraps tr to cache its responses in the responses directory of cacheDir.
+func newCacheTranslator(tr Translator, cacheDir, backend string, ttl time.Duration, verbose io.Writer) *cacheTranslator {
+	return &cacheTranslator{
+		Translator: tr,
+		store:      cache.New(filepath.Join(cacheDir, "responses")),
+		backend:    backend,
+		ttl:        ttl,
+		verbose:    verbose,
+	}
+}
+
+// noCacheKey is the context key of withoutCache.
+type noCacheKey struct{}
+
+// withoutCache returns a context whose requests are made even if a response is cached.
+// The new response replaces the cached one.
+func withoutCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, noCacheKey{}, true)
+}
+
+// key returns the key of a request: the backend, the model, the prompts and the hash of the input.
+func (ct *cacheTranslator) key(systemPrompt, prompt, input, model string) string {
+	return cache.Key(ct.backend, model, systemPrompt, prompt, cache.Key(input))
+}
+
+func (ct *cacheTranslator) request(ctx context.Context, systemPrompt, prompt, input, model string) (string, error) {
+	key := ct.key(systemPrompt, prompt, input, model)
+	if text, ok := ct.get(ctx, key); ok {
+		return text, nil
+	}
+	text, err := ct.Translator.request(ctx, systemPrompt, prompt, input, model)
+	if err != nil {
+		return "", err
+	}
+	ct.put(key, text)
+	return text, nil
+}
+
+func (ct *cacheTranslator) requestStream(ctx context.Context, systemPrompt, prompt, input, model string, w io.Writer) error {
+	key := ct.key(systemPrompt, prompt, input, model)
+	if text, ok := ct.get(ctx, key); ok {
+		_, err := io.WriteString(w, text)
+		return err
+	}
+	text, err := streamOrWrite(ctx, ct.Translator, systemPrompt, prompt, input, model, w)
+	if err != nil {
+		return err
+	}
+	ct.put(key, text)
+	return nil
+}
+
+// requestCandidates caches the n responses together, apart from the single response to the same request.
+func (ct *cacheTranslator) requestCandidates(ctx context.Context, systemPrompt, prompt, input, model string, n int) ([]string, error) {
+	key := cache.Key(ct.key(systemPrompt, prompt, input, model), strconv.Itoa(n))
+	if text, ok := ct.get(ctx, key); ok {
+		var texts []string
+		if err := json.Unmarshal([]byte(text), &texts); err == nil {
+			return texts, nil
+		}
+	}
+	texts, err := requestCandidates(ctx, ct.Translator, systemPrompt, prompt, input, model, n)
+	if err != nil {
+		return nil, err
+	}
+	if b, err := json.Marshal(texts); err == nil {
+		ct.put(key, string(b))
+	}
+	return texts, nil
+}
+
+func (ct *cacheTranslator) usage() Usage {
+	return usageOf(ct.Translator)
+}
+
+// get returns the cached response of key, if any.
+func (ct *cacheTranslator) get(ctx context.Context, key string) (string, bool) {
+	if ctx.Value(noCacheKey{}) != nil {
+		return "", false
+	}
+	text, ok := ct.store.Get(key, ct.ttl)
+	if ct.verbose != nil {
+		if ok {
+			fmt.Fprintf(ct.verbose, "Cache: hit %s\n", key[:12])
+		} else {
+			fmt.Fprintf(ct.verbose, "Cache: miss %s\n", key[:12])
+		}
+	}
+	return text, ok
+}
+
+// put caches the response of key. The response is still returned if it cannot be cached.
+func (ct *cacheTranslator) put(key, text string) {
+	if err := ct.store.Put(key, text); err != nil && ct.verbose != nil {
+		fmt.Fprintf(ct.verbose, "Cache: failed to store %s: %v\n", key[:12], err)
+	}
+}
@@ -0,0 +1,85 @@
+package cli_test
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/catatsuy/bento/internal/cli"
+)
+
+// runCached runs bento with input and returns the output, the standard error and the number of requests.
+func runCached(t *testing.T, input string, args ...string) (string, string, int) {
+	t.Helper()
+
+	m := newMockModel("Fix typo in README")
+	out, errOut := mustRunBento(t, m.translator(), env{stdin: input}, args...)
+	return out, errOut, len(m.calls())
+}
+
+func TestRun_cache(t *testing.T) {
+	t.Setenv("BENTO_CACHE_DIR", t.TempDir())
+
+	diff := "diff --git a/README.md b/README.md\n-teh\n+the\n"
+	out, errOut, requests := runCached(t, diff, "-commit", "-cache", "-verbose")
+	if requests != 1 || out != "Fix typo in README\n" {
+		t.Errorf("expected a request, got %d requests and %q", requests, out)
+	}
+	if !strings.HasPrefix(errOut, "Cache: miss ") {
+		t.Errorf("expected a cache miss, got %q", errOut)
+	}
+
+	out, errOut, requests = runCached(t, diff, "-commit", "-cache", "-verbose")
+	if requests != 0 || out != "Fix typo in README\n" {
+		t.Errorf("expected the cached response, got %d requests and %q", requests, out)
+	}
+	if !strings.HasPrefix(errOut, "Cache: hit ") {
+		t.Errorf("expected a cache hit, got %q", errOut)
+	}
+
+	// The cache is used for streaming, and nothing is printed without -verbose.
+	out, errOut, requests = runCached(t, diff, "-commit", "-cache", "-stream")
+	if requests != 0 || out != "Fix typo in README\n" || errOut != "" {
+		t.Errorf("expected the cached response, got %d requests, %q and %q", requests, out, errOut)
+	}
+
+	// Another mode, model or input is requested.
+	for _, args := range [][]string{
+		{"-review", "-cache"},
+		{"-commit", "-cache", "-model", "other"},
+		{"-commit", "-cache", "-system", "Use the imperative mood."},
+	} {
+		if _, _, requests := runCached(t, diff, args...); requests != 1 {
+			t.Errorf("%q: expected a request, got %d", args, requests)
+		}
+	}
+	if _, _, requests := runCached(t, diff+"+more\n", "-commit", "-cache"); requests != 1 {
+		t.Errorf("expected a request for another input, got %d", requests)
+	}
+
+	// Without -cache, the request is always made.
+	if _, _, requests := runCached(t, diff, "-commit"); requests != 1 {
+		t.Errorf("expected a request without -cache, got %d", requests)
+	}
+}
+
+func TestRun_cacheTTL(t *testing.T) {
+	t.Setenv("BENTO_CACHE_DIR", t.TempDir())
+
+	runCached(t, "input", "-prompt", "Summarize:", "-cache")
+	_, errOut, requests := runCached(t, "input", "-prompt", "Summarize:", "-cache", "-cache-ttl", "1ns", "-verbose")
+	if requests != 1 || !strings.HasPrefix(errOut, "Cache: miss ") {
+		t.Errorf("expected an expired response to be requested again, got %d requests and %q", requests, errOut)
+	}
+}
+
+func TestRun_cacheMultiMode(t *testing.T) {
+	t.Setenv("BENTO_CACHE_DIR", t.TempDir())
+
+	_, errOut, status := runBento(t, &MockTranslator{}, env{stdin: "hello"}, "-translate", "-cache")
+	if status != ExitCodeFail {
+		t.Errorf("ExitStatus=%d, want %d", status, ExitCodeFail)
+	}
+	if !strings.Contains(errOut, "can only be used in single mode") {
+		t.Errorf("unexpected error %q", errOut)
+	}
+}
@@ -0,0 +1,163 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"golang.org/x/term"
+)
+
+// candidatesTranslator is implemented by Translators that can request several responses at once.
+type candidatesTranslator interface {
+	requestCandidates(ctx context.Context, systemPrompt, prompt, input, model string, n int) ([]string, error)
+}
+
+// requestCandidates requests n responses from tr: in one request if tr supports it
+// and in n concurrent requests otherwise.
+func requestCandidates(ctx context.Context, tr Translator, systemPrompt, prompt, input, model string, n int) ([]string, error) {
+	if ct, ok := tr.(candidatesTranslator); ok {
+		return ct.requestCandidates(ctx, systemPrompt, prompt, input, model, n)
+	}
+	return requestEach(ctx, tr, systemPrompt, prompt, input, model, n)
+}
+
+// requestEach sends n identical requests concurrently and returns the responses.
+func requestEach(ctx context.Context, tr Translator, systemPrompt, prompt, input, model string, n int) ([]string, error) {
+	texts := make([]string, n)
+	errs := make([]error, n)
+	var wg sync.WaitGroup
+	for i := range n {
+		wg.Go(func() {
+			texts[i], errs[i] = tr.request(ctx, systemPrompt, prompt, input, model)
+		})
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return texts, nil
+}
+
+// uniqueCandidates trims the candidates and removes the empty and duplicate ones.
+func uniqueCandidates(candidates []string) []string {
+	seen := make(map[string]bool, len(candidates))
+	var unique []string
+	for _, c := range candidates {
+		c = strings.TrimSpace(c)
+		if c == "" || seen[c] {
+			continue
+		}
+		seen[c] = true
+		unique = append(unique, c)
+	}
+	return unique
+}
+
+// candidateMarker is the line between the suggestions of -n written for scripts
+// when a suggestion can span several lines, such as a commit message with a body.
+const candidateMarker = "---"
+
+// candidateSeparator returns what separates the suggestions of -n written for scripts:
+// NUL with -z, candidateMarker between blank lines for multi-line suggestions, or a newline.
+func candidateSeparator(nul, multiline bool) string {
+	switch {
+	case nul:
+		return "\x00"
+	case multiline:
+		return "\n\n" + candidateMarker + "\n\n"
+	default:
+		return "\n"
+	}
+}
+
+// canPick reports whether the candidates of -n can be chosen interactively.
+func (c *CLI) canPick() bool {
+	return c.isStdinTerminal && c.isStdoutTerminal
+}
+
+// runCandidates requests n suggestions and passes each of them to check. If the user can pick one,
+// the chosen suggestion is written to the output stream and applied with -apply.
+// Otherwise the suggestions are written separated by sep, which also ends the output if it is NUL.
+func (c *CLI) runCandidates(ctx context.Context, systemPrompt, prompt, input, model string, n int, check func(ctx context.Context, suggestion string) (string, error), sep string, commit, apply bool, terminal io.Reader) int {
+	generate := func(ctx context.Context) ([]string, error) {
+		candidates, err := requestCandidates(ctx, c.translator, systemPrompt, prompt, input, model, n)
+		if err != nil {
+			return nil, err
+		}
+		for i, candidate := range candidates {
+			if candidates[i], err = check(ctx, candidate); err != nil {
+				return nil, err
+			}
+		}
+		candidates = uniqueCandidates(candidates)
+		if len(candidates) == 0 {
+			return nil, fmt.Errorf("no suggestion found")
+		}
+		return candidates, nil
+	}
+
+	candidates, err := generate(ctx)
+	if err != nil {
+		return c.requestError(err)
+	}
+
+	if !c.canPick() {
+		end := "\n"
+		if sep == "\x00" {
+			end = sep
+		}
+		fmt.Fprintf(c.outStream, "%s%s", strings.Join(candidates, sep), end)
+		return ExitCodeOK
+	}
+
+	chosen, ok, err := c.pick(ctx, candidates, generate, terminal)
+	if err != nil {
+		return c.requestError(err)
+	}
+	if !ok {
+		fmt.Fprintf(c.errStream, "Nothing was chosen.\n")
+		return ExitCodeOK
+	}
+	fmt.Fprintf(c.outStream, "%s\n", chosen)
+
+	if apply {
+		regenerate := func(ctx context.Context) (string, error) {
+			suggestion, err := c.translator.request(ctx, systemPrompt, prompt, input, model)
+			if err != nil {
+				return "", err
+			}
+			if suggestion, err = check(ctx, suggestion); err != nil {
+				return "", err
+			}
+			fmt.Fprintf(c.outStream, "%s\n", suggestion)
+			return suggestion, nil
+		}
+		return c.apply(ctx, commit, chosen, regenerate, terminal)
+	}
+	return ExitCodeOK
+}
+
+// pick shows the picker on the terminal, switching it to raw mode to read the keys.
+func (c *CLI) pick(ctx context.Context, candidates []string, generate func(ctx context.Context) ([]string, error), terminal io.Reader) (string, bool, error) {
+	p := &picker{keys: bufio.NewReader(terminal), out: c.outStream}
+	if f, ok := terminal.(*os.File); ok {
+		fd := int(f.Fd())
+		state, err := term.MakeRaw(fd)
+		if err != nil {
+			return "", false, err
+		}
+		defer term.Restore(fd, state)
+		p.cooked = func() func() {
+			term.Restore(fd, state)
+			return func() { term.MakeRaw(fd) }
+		}
+	}
+	return p.pick(ctx, candidates, generate)
+}
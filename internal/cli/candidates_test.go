@@ -0,0 +1,219 @@
+package cli_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+
+	. "github.com/catatsuy/bento/internal/cli"
+	"github.com/catatsuy/bento/internal/gemini"
+)
+
+// runCandidates runs bento with keys on the terminal and returns the output, the standard error and the status.
+func runCandidates(t *testing.T, tr Translator, keys string, pick bool, args ...string) (string, string, int) {
+	t.Helper()
+
+	return runBento(t, tr, env{stdin: keys, terminal: true, stdoutTerminal: pick}, args...)
+}
+
+func TestRun_candidatesNotTerminal(t *testing.T) {
+	input := setupConfig(t, "", "")
+
+	m := newMockModel("Fix typo", " Fix typo\n", "Update README")
+	out, errOut, status := runCandidates(t, m.translator(), "", false, "-commit", "-n", "3", "-file", input)
+	if status != ExitCodeOK {
+		t.Fatalf("ExitStatus=%d, want %d: %s", status, ExitCodeOK, errOut)
+	}
+	if requests := len(m.calls()); requests != 3 {
+		t.Errorf("expected 3 requests, got %d", requests)
+	}
+
+	// The duplicate suggestion is printed once, one suggestion per line.
+	got := strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+	want := []string{"Fix typo", "Update README"}
+	if diff := cmp.Diff(want, got, cmpopts.SortSlices(func(a, b string) bool { return a < b })); diff != "" {
+		t.Errorf("output mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestRun_candidatesSeparator(t *testing.T) {
+	input := setupConfig(t, "", "")
+
+	messages := []string{"Fix typo\n\nIt was misspelled.", "Update README\n\nIt describes -n."}
+	sortedSplit := func(out, sep string) []string {
+		got := strings.Split(out, sep)
+		slices.Sort(got)
+		return got
+	}
+
+	// Multi-line commit messages are separated by a marker line between blank lines.
+	out, errOut, status := runCandidates(t, newMockModel(messages...).translator(), "", false, "-commit", "-commit-style", "plain", "-n", "2", "-file", input)
+	if status != ExitCodeOK {
+		t.Fatalf("ExitStatus=%d, want %d: %s", status, ExitCodeOK, errOut)
+	}
+	if diff := cmp.Diff(messages, sortedSplit(strings.TrimSuffix(out, "\n"), "\n\n---\n\n")); diff != "" {
+		t.Errorf("output mismatch (-want +got):\n%s", diff)
+	}
+
+	// With -z, each suggestion ends with NUL.
+	out, errOut, status = runCandidates(t, newMockModel(messages...).translator(), "", false, "-commit", "-commit-style", "plain", "-n", "2", "-z", "-file", input)
+	if status != ExitCodeOK {
+		t.Fatalf("ExitStatus=%d, want %d: %s", status, ExitCodeOK, errOut)
+	}
+	if !strings.HasSuffix(out, "\x00") {
+		t.Errorf("expected the output to end with NUL, got %q", out)
+	}
+	if diff := cmp.Diff(messages, sortedSplit(strings.TrimSuffix(out, "\x00"), "\x00")); diff != "" {
+		t.Errorf("output mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestRun_candidatesPicker(t *testing.T) {
+	input := setupConfig(t, "", "")
+
+	tests := []struct {
+		name    string
+		keys    string
+		want    string
+		wantErr string
+	}{
+		{"edit", "efeature/custom\n\r", "feature/custom\n", ""},
+		{"regenerate", "r\r", "feature/regenerated\n", ""},
+		{"quit", "q", "", "Nothing was chosen.\n"},
+		{"end of input", "", "", "Nothing was chosen.\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr := newMockModel("feature/a", "feature/b", "feature/regenerated").translator()
+			out, errOut, status := runCandidates(t, tr, tt.keys, true, "-branch", "-n", "2", "-file", input)
+			if status != ExitCodeOK {
+				t.Fatalf("ExitStatus=%d, want %d: %s", status, ExitCodeOK, errOut)
+			}
+			if !strings.Contains(out, "Choose a suggestion") {
+				t.Errorf("expected the picker to be shown, got %q", out)
+			}
+			if tt.want != "" && !strings.HasSuffix(out, tt.want) {
+				t.Errorf("expected the output to end with %q, got %q", tt.want, out)
+			}
+			if errOut != tt.wantErr {
+				t.Errorf("stderr=%q, want %q", errOut, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPick(t *testing.T) {
+	candidates := []string{"first", "second", "third"}
+	tests := []struct {
+		keys   string
+		want   string
+		wantOK bool
+	}{
+		{"\r", "first", true},
+		{"\x1b[B\x1b[B\x1b[A\r", "second", true},
+		{"jj\n", "third", true},
+		{"k\r", "third", true},
+		{"2", "second", true},
+		{"9\r", "first", true},
+		{"r\r", "regenerated", true},
+		{"\x03", "", false},
+		{"\x1b", "", false},
+		{"x", "", false},
+	}
+	for _, tt := range tests {
+		got, ok, err := Pick(t.Context(), tt.keys, slices.Clone(candidates), []string{"regenerated"})
+		if err != nil {
+			t.Errorf("%q: unexpected error %v", tt.keys, err)
+			continue
+		}
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("%q: got %q, %v, want %q, %v", tt.keys, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestRun_candidatesCache(t *testing.T) {
+	input := setupConfig(t, "", "")
+	t.Setenv("BENTO_CACHE_DIR", t.TempDir())
+
+	m := newMockModel("Fix typo", "Update README")
+	tr := m.translator()
+	for range 2 {
+		if _, errOut, status := runCandidates(t, tr, "", false, "-commit", "-n", "2", "-cache", "-file", input); status != ExitCodeOK {
+			t.Fatalf("ExitStatus=%d, want %d: %s", status, ExitCodeOK, errOut)
+		}
+	}
+	if requests := len(m.calls()); requests != 2 {
+		t.Errorf("expected the candidates to be cached, got %d requests", requests)
+	}
+
+	// A single suggestion is cached apart from the candidates.
+	if _, errOut, status := runCandidates(t, tr, "", false, "-commit", "-cache", "-file", input); status != ExitCodeOK {
+		t.Fatalf("ExitStatus=%d, want %d: %s", status, ExitCodeOK, errOut)
+	}
+	if requests := len(m.calls()); requests != 3 {
+		t.Errorf("expected a request for a single suggestion, got %d requests", requests)
+	}
+}
+
+func TestRun_candidatesOptions(t *testing.T) {
+	input := setupConfig(t, "", "")
+
+	tests := [][]string{
+		{"-commit", "-n", "0", "-file", input},
+		{"-translate", "-n", "2", "-file", input},
+		{"-commit", "-n", "2", "-stream", "-file", input},
+		{"-commit", "-n", "2", "-apply", "-file", input},
+		{"-commit", "-z", "-file", input},
+	}
+	for _, args := range tests {
+		m := newMockModel("ok")
+		_, _, status := runCandidates(t, m.translator(), "", false, args...)
+		if status != ExitCodeFail {
+			t.Errorf("%v: ExitStatus=%d, want %d", args, status, ExitCodeFail)
+		}
+		if requests := len(m.calls()); requests != 0 {
+			t.Errorf("%v: expected no request, got %d", args, requests)
+		}
+	}
+}
+
+func TestRequestCandidates_chatCompletions(t *testing.T) {
+	var ns []int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		payload := &gemini.Payload{}
+		if err := json.NewDecoder(r.Body).Decode(payload); err != nil {
+			t.Fatal(err)
+		}
+		ns = append(ns, payload.N)
+		if payload.N == 0 {
+			fmt.Fprint(w, `{"choices":[{"index":0,"message":{"role":"assistant","content":"third"},"finish_reason":"stop"}]}`)
+			return
+		}
+		// The server returns fewer choices than asked for.
+		fmt.Fprint(w, `{"choices":[{"index":0,"message":{"role":"assistant","content":"first"},"finish_reason":"stop"},{"index":1,"message":{"role":"assistant","content":"second"},"finish_reason":"stop"}]}`)
+	}))
+	defer server.Close()
+
+	tr, err := NewOpenAICompatibleTranslator(server.URL, "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := RequestCandidates(t.Context(), tr, "", "Suggest: ", "diff", "model", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"first", "second", "third"}, got); diff != "" {
+		t.Errorf("candidates mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]int{3, 0}, ns); diff != "" {
+		t.Errorf("n of the requests mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,142 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/catatsuy/bento/internal/catalog"
+	"github.com/catatsuy/bento/internal/placeholder"
+)
+
+// catalogOptions are the options of "bento translate-catalog".
+type catalogOptions struct {
+	// format is the format of the catalog. If empty, it is detected from the extension of the file.
+	format string
+	// output is the file written in the target language. If empty, the catalog is written to the output stream.
+	output string
+}
+
+// catalogPrompt returns the prompt of "bento translate-catalog".
+func catalogPrompt(language string) string {
+	return "Translate the values of the following JSON object to " + language + ". They are the messages of the user interface of an application.\n\n"
+}
+
+// translateCatalog translates the messages of the catalog file at path.
+// Messages already translated in the file or in the existing output file are skipped, and
+// the placeholders of the messages are replaced with ⟦n⟧ so that they are kept unchanged.
+func (c *CLI) translateCatalog(ctx context.Context, systemPrompt, prompt, useModel string, opts multiOptions, path string, copts catalogOptions) error {
+	format := copts.format
+	if format == "" {
+		var err error
+		format, err = catalog.FormatOf(path)
+		if err != nil {
+			return err
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	cat, err := catalog.Parse(format, data)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	existing := map[string]string{}
+	if copts.output != "" {
+		data, err := os.ReadFile(copts.output)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		if err == nil {
+			existing, err = catalog.Translations(format, data)
+			if err != nil {
+				return fmt.Errorf("%s: %w", copts.output, err)
+			}
+		}
+	}
+
+	messages := cat.Messages()
+	protected := make([][]string, len(messages))
+	var items []batchItem
+	for i, m := range messages {
+		if m.Translation != "" || strings.TrimSpace(m.Text) == "" {
+			continue
+		}
+		// JSON and YAML files keep the source text of untranslated messages, which is not a translation.
+		if t, ok := existing[m.ID]; ok && (format == catalog.FormatPO || t != m.Text) {
+			m.Translation = t
+			continue
+		}
+		var text string
+		text, protected[i] = placeholder.Protect(m.Text, catalog.Placeholders)
+		items = append(items, batchItem{id: strconv.Itoa(i), text: text})
+	}
+
+	if len(items) > 0 {
+		translations, err := c.translateBatches(ctx, systemPrompt, prompt, useModel, opts, items)
+		if err != nil {
+			return err
+		}
+		for _, item := range items {
+			i, _ := strconv.Atoi(item.id)
+			m := messages[i]
+			t, ok := translations[item.id]
+			if !ok {
+				fmt.Fprintf(c.errStream, "Warning: The message %q was not translated.\n", m.ID)
+				continue
+			}
+			restored, err := placeholder.Restore(t, protected[i])
+			if err != nil {
+				fmt.Fprintf(c.errStream, "Warning: The message %q was not translated because the response did not keep the placeholders: %v\n", m.ID, err)
+				continue
+			}
+			m.Translation = restored
+		}
+	}
+
+	var b bytes.Buffer
+	if err := cat.Write(&b); err != nil {
+		return err
+	}
+	if copts.output == "" {
+		_, err := c.outStream.Write(b.Bytes())
+		return err
+	}
+	return writeFileAtomic(copts.output, b.Bytes())
+}
+
+// writeFileAtomic writes data to a temporary file next to path and renames it to path,
+// so that path is never left half written.
+func writeFileAtomic(path string, data []byte) error {
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	// Keep the permissions of an existing file.
+	mode := fs.FileMode(0o644)
+	if fi, err := os.Stat(path); err == nil {
+		mode = fi.Mode().Perm()
+	}
+	if err := os.Chmod(f.Name(), mode); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
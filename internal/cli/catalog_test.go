@@ -0,0 +1,196 @@
+package cli_test
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	. "github.com/catatsuy/bento/internal/cli"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+// sortStrings compares slices of strings regardless of their order.
+var sortStrings = cmpopts.SortSlices(func(a, b string) bool { return a < b })
+
+// catalogTranslator returns a MockTranslator that upper-cases the values of the JSON objects
+// and wraps the response in a code block, and records the texts it was asked to translate.
+func catalogTranslator(t *testing.T, texts *[]string) *MockTranslator {
+	var mu sync.Mutex
+	return &MockTranslator{
+		TranslateTextFunc: func(ctx context.Context, systemPrompt, prompt, text, model string) (string, error) {
+			var values map[string]string
+			if err := json.Unmarshal([]byte(text), &values); err != nil {
+				t.Errorf("expected a JSON object, got %q: %v", text, err)
+				return "", err
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			for k, v := range values {
+				*texts = append(*texts, v)
+				values[k] = upperText(v)
+			}
+			b, err := json.Marshal(values)
+			if err != nil {
+				return "", err
+			}
+			return "```json\n" + string(b) + "\n```", nil
+		},
+	}
+}
+
+func TestRun_translateCatalogJSON(t *testing.T) {
+	var texts []string
+	out, _ := mustRunBento(t, catalogTranslator(t, &texts), env{terminal: true}, "translate-catalog", "-language", "ja", "testdata/catalog/en.json")
+
+	expected := `{
+  "app": {
+    "title": "BENTO",
+    "greeting": "HELLO, {name}!",
+    "items": "YOU HAVE %d ITEMS"
+  },
+  "menu": [
+    "OPEN",
+    "CLOSE"
+  ],
+  "count": 3,
+  "empty": ""
+}
+`
+	if diff := cmp.Diff(expected, out); diff != "" {
+		t.Errorf("catalog mismatch (-expected +actual):\n%s", diff)
+	}
+
+	// The placeholders are not sent to the model.
+	for _, text := range texts {
+		if strings.Contains(text, "{name}") || strings.Contains(text, "%d") {
+			t.Errorf("expected the placeholders to be protected, got %q", text)
+		}
+	}
+}
+
+func TestRun_translateCatalogBatches(t *testing.T) {
+	m := &mockModel{respond: func(r request) string { return r.input }}
+
+	// The five messages are 5, 11, 18, 4 and 5 characters long with the placeholders.
+	mustRunBento(t, m.translator(), env{terminal: true}, "translate-catalog", "-limit", "20", "testdata/catalog/en.json")
+	calls := m.calls()
+	if len(calls) != 3 {
+		t.Errorf("expected 3 requests, got %d", len(calls))
+	}
+	for _, r := range calls {
+		if !strings.Contains(r.system, "JSON object") {
+			t.Errorf("expected the system prompt to describe the JSON object, got %q", r.system)
+		}
+	}
+}
+
+func TestRun_translateCatalogSkipsTranslated(t *testing.T) {
+	output := filepath.Join(t.TempDir(), "ja.json")
+	existing := `{"app": {"title": "弁当", "greeting": "Hello, {name}!"}, "menu": ["開く"]}`
+	if err := os.WriteFile(output, []byte(existing), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var texts []string
+	if out, _ := mustRunBento(t, catalogTranslator(t, &texts), env{terminal: true}, "translate-catalog", "-o", output, "testdata/catalog/en.json"); out != "" {
+		t.Errorf("expected no output, got %q", out)
+	}
+
+	// The greeting of the existing file is still the source text, so it is translated.
+	expectedTexts := []string{"Hello, ⟦0⟧!", "You have ⟦0⟧ items", "Close"}
+	if diff := cmp.Diff(expectedTexts, texts, sortStrings); diff != "" {
+		t.Errorf("translated texts mismatch (-expected +actual):\n%s", diff)
+	}
+
+	b, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{
+  "app": {
+    "title": "弁当",
+    "greeting": "HELLO, {name}!",
+    "items": "YOU HAVE %d ITEMS"
+  },
+  "menu": [
+    "開く",
+    "CLOSE"
+  ],
+  "count": 3,
+  "empty": ""
+}
+`
+	if diff := cmp.Diff(expected, string(b)); diff != "" {
+		t.Errorf("catalog mismatch (-expected +actual):\n%s", diff)
+	}
+}
+
+func TestRun_translateCatalogPO(t *testing.T) {
+	var texts []string
+	out, _ := mustRunBento(t, catalogTranslator(t, &texts), env{terminal: true}, "translate-catalog", "-language", "ja", "testdata/catalog/ja.po")
+
+	// "Open" is already translated, while the same text in the menu context is not.
+	expectedTexts := []string{"Hello, ⟦0⟧!", "Open", "⟦0⟧ files", "Line one\nLine two"}
+	if diff := cmp.Diff(expectedTexts, texts, sortStrings); diff != "" {
+		t.Errorf("translated texts mismatch (-expected +actual):\n%s", diff)
+	}
+
+	for _, line := range []string{
+		`msgstr "HELLO, %s!"`,
+		"msgid \"Open\"\nmsgstr \"開く\"",
+		"msgctxt \"menu\"\nmsgid \"Open\"\nmsgstr \"OPEN\"",
+		`msgstr[0] "%d FILES"`,
+		"msgstr \"\"\n\"LINE ONE\\n\"\n\"LINE TWO\"",
+	} {
+		if !strings.Contains(out, line) {
+			t.Errorf("expected %q in the output, got:\n%s", line, out)
+		}
+	}
+}
+
+func TestRun_translateCatalogLostPlaceholder(t *testing.T) {
+	tr := newMockModel(`{"1": "BENTO", "2": "HELLO!", "3": "YOU HAVE ⟦0⟧ ITEMS"}`).translator()
+	out, errOut := mustRunBento(t, tr, env{terminal: true}, "translate-catalog", "testdata/catalog/en.json")
+
+	// The greeting lost its placeholder and the menu is missing from the response, so they keep the source text.
+	for _, s := range []string{`"title": "BENTO"`, `"greeting": "Hello, {name}!"`, `"items": "YOU HAVE %d ITEMS"`, `"Open"`} {
+		if !strings.Contains(out, s) {
+			t.Errorf("expected %q in the output, got:\n%s", s, out)
+		}
+	}
+	for _, s := range []string{`"/app/greeting"`, `"/menu/0"`, `"/menu/1"`} {
+		if !strings.Contains(errOut, "Warning: The message "+s) {
+			t.Errorf("expected a warning for %s, got %q", s, errOut)
+		}
+	}
+}
+
+func TestRun_translateCatalogInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"no file", []string{"translate-catalog"}, "Specify a catalog file"},
+		{"built-in mode", []string{"translate-catalog", "-translate", "testdata/catalog/en.json"}, "built-in modes"},
+		{"unknown format", []string{"translate-catalog", "testdata/test.txt"}, "unknown catalog format"},
+		{"output without translate-catalog", []string{"-translate", "-o", "ja.json"}, "can only be used with 'translate-catalog'"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &mockModel{respond: func(r request) string { return r.input }}
+			_, errOut, status := runBento(t, m.translator(), env{terminal: true}, tt.args...)
+			if status != ExitCodeFail {
+				t.Errorf("ExitStatus=%d, want %d", status, ExitCodeFail)
+			}
+			if !strings.Contains(errOut, tt.want) {
+				t.Errorf("expected %q in the error, got %q", tt.want, errOut)
+			}
+		})
+	}
+}
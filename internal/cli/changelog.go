@@ -0,0 +1,131 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// changelogSections is the format of the release notes, shared by the batches and the final request
+// so that the notes of the batches can be merged.
+const changelogSections = `Group the changes under the following Markdown headings, in this order, leaving out the empty ones:
+### ⚠ Breaking Changes
+### Features
+### Bug Fixes
+### Other Changes
+Write one bullet per change that matters to the users of the project, in the past tense, followed by the short hashes of its commits in parentheses. Combine the commits of the same change and leave out the changes that do not matter to the users, such as typo fixes of comments.
+`
+
+// changelogPrompt returns the prompt of the batches of the commits of "bento changelog".
+func changelogPrompt(language string) string {
+	prompt := "Write release notes for GitHub Releases from the following commits, each starting with its short hash. A change is breaking if its commit message says so, such as with \"!\" after the type or a \"BREAKING CHANGE:\" footer.\n" + changelogSections
+	if language != "" {
+		prompt += "Write the release notes in " + language + ".\n"
+	}
+	return prompt + "Output only the Markdown of the release notes.\n\n"
+}
+
+// changelogMergePrompt returns the prompt of the final request merging the release notes of the batches.
+func changelogMergePrompt(language string) string {
+	prompt := "The following release notes, separated by ---, were written for consecutive parts of the commits of one release. Merge them into the release notes of the release.\n" + changelogSections
+	if language != "" {
+		prompt += "Write the release notes in " + language + ".\n"
+	}
+	return prompt + "Output only the Markdown of the release notes.\n\n"
+}
+
+// changelogCommits returns the commits of rng, oldest first, each as its short hash and its message,
+// followed by its diff if withDiffs is set. A diff is cut to keep the commit within limit characters.
+// Merge commits are left out.
+func changelogCommits(ctx context.Context, rng string, withDiffs bool, limit int) ([]string, error) {
+	// rng is given by the user and must not be taken for an option of git.
+	out, err := gitOutput(ctx, "log", "--reverse", "--no-merges", "--format=%h%x00%B%x1e", "--end-of-options", rng)
+	if err != nil {
+		return nil, err
+	}
+
+	var commits []string
+	for record := range strings.SplitSeq(out, "\x1e") {
+		hash, message, ok := strings.Cut(strings.TrimSpace(record), "\x00")
+		if !ok {
+			continue
+		}
+		commit := "commit " + hash + "\n" + strings.TrimSpace(message) + "\n"
+		if withDiffs {
+			diff, err := gitOutput(ctx, "show", "-w", "--format=", hash)
+			if err != nil {
+				return nil, err
+			}
+			if room := limit - utf8.RuneCountInString(commit); utf8.RuneCountInString(diff) > room {
+				diff = string([]rune(diff)[:max(room, 0)]) + "\n[The rest of the diff is left out.]\n"
+			}
+			commit += "\n" + diff
+		}
+		commits = append(commits, commit)
+	}
+	return commits, nil
+}
+
+// changelog writes the release notes of the commits of rng. The commits are sent in batches of at most
+// opts.limit like the chunks of multi mode, and the notes of the batches are merged in a final request.
+func (c *CLI) changelog(ctx context.Context, systemPrompt, prompt, language, useModel string, opts multiOptions, rng string, withDiffs bool) error {
+	// "v1.0.0" is the range from the tag to HEAD.
+	if !strings.Contains(rng, "..") {
+		rng += "..HEAD"
+	}
+	commits, err := changelogCommits(ctx, rng, withDiffs, opts.limit)
+	if err != nil {
+		return err
+	}
+	if len(commits) == 0 {
+		return fmt.Errorf("there are no commits in %s", rng)
+	}
+
+	measure := utf8.RuneCountInString
+	if opts.chunker != nil {
+		measure = opts.chunker.measure
+	}
+	var (
+		batches []string
+		batch   strings.Builder
+	)
+	for _, commit := range commits {
+		if batch.Len() > 0 && measure(batch.String())+measure(commit) > opts.limit {
+			batches = append(batches, batch.String())
+			batch.Reset()
+		}
+		if batch.Len() > 0 {
+			batch.WriteString("\n")
+		}
+		batch.WriteString(commit)
+	}
+	batches = append(batches, batch.String())
+
+	var notes []string
+	produce := func(send func(chunk string) error) error {
+		for _, batch := range batches {
+			if err := send(batch); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	consume := func(text string) error {
+		notes = append(notes, strings.TrimSpace(text))
+		return nil
+	}
+	if err := c.requestChunks(ctx, systemPrompt, prompt, useModel, opts, produce, consume); err != nil {
+		return err
+	}
+
+	text := notes[0]
+	if len(notes) > 1 {
+		text, err = c.translator.request(ctx, systemPrompt, changelogMergePrompt(language), strings.Join(notes, "\n\n---\n\n"), useModel)
+		if err != nil {
+			return err
+		}
+	}
+	_, err = fmt.Fprintf(c.outStream, "%s\n", strings.TrimSpace(text))
+	return err
+}
@@ -0,0 +1,139 @@
+package cli_test
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	. "github.com/catatsuy/bento/internal/cli"
+)
+
+// runChangelog runs "bento changelog" with args and returns the output, the standard error,
+// the status and the prompts and the inputs of the requests in order.
+func runChangelog(t *testing.T, args ...string) (string, string, int, []string, []string) {
+	t.Helper()
+
+	m := &mockModel{respond: func(r request) string {
+		if strings.HasPrefix(r.prompt, "The following release notes") {
+			return "### Features\n- merged\n"
+		}
+		return "### Features\n- part\n"
+	}}
+	out, errOut, status := runBento(t, m.translator(), env{terminal: true}, append([]string{"changelog"}, args...)...)
+	var prompts, inputs []string
+	for _, r := range m.calls() {
+		prompts = append(prompts, r.prompt)
+		inputs = append(inputs, r.input)
+	}
+	return out, errOut, status, prompts, inputs
+}
+
+// setupTags creates the tags v1.0.0 and v1.1.0 with three commits between them.
+func setupTags(t *testing.T) {
+	t.Helper()
+
+	setupRepo(t)
+	git(t, "tag", "v1.0.0")
+	writeFile(t, "main.go", "package main\n")
+	git(t, "add", "main.go")
+	git(t, "commit", "-q", "-m", "feat: add the entry point")
+	writeFile(t, "main.go", "package main\n\nfunc main() {}\n")
+	git(t, "commit", "-q", "-a", "-m", "fix: define main", "-m", "BREAKING CHANGE: main is required.")
+	writeFile(t, "README.md", "# bento\n\nUsage\n")
+	git(t, "commit", "-q", "-a", "-m", "docs: describe the usage")
+	git(t, "tag", "v1.1.0")
+}
+
+func TestRun_changelog(t *testing.T) {
+	setupTags(t)
+
+	out, errOut, status, prompts, inputs := runChangelog(t, "-language", "Japanese", "v1.0.0..v1.1.0")
+	if status != ExitCodeOK {
+		t.Fatalf("ExitStatus=%d, want %d: %s", status, ExitCodeOK, errOut)
+	}
+	if out != "### Features\n- part\n" {
+		t.Errorf("unexpected output %q", out)
+	}
+	if len(inputs) != 1 {
+		t.Fatalf("expected one request, got %d", len(inputs))
+	}
+	first, second, third := strings.Index(inputs[0], "feat: add the entry point"), strings.Index(inputs[0], "BREAKING CHANGE: main is required."), strings.Index(inputs[0], "docs: describe the usage")
+	if first < 0 || second < first || third < second {
+		t.Errorf("expected the commit messages in order, got %q", inputs[0])
+	}
+	if strings.Contains(inputs[0], "Initial commit") || strings.Contains(inputs[0], "+package main") {
+		t.Errorf("expected only the messages of the range, got %q", inputs[0])
+	}
+	if !strings.Contains(prompts[0], "### Bug Fixes") || !strings.Contains(prompts[0], "in Japanese") {
+		t.Errorf("unexpected prompt %q", prompts[0])
+	}
+
+	// A tag alone is the range up to HEAD, and -diffs sends the diffs.
+	_, errOut, status, _, inputs = runChangelog(t, "-diffs", "v1.0.0")
+	if status != ExitCodeOK {
+		t.Fatalf("ExitStatus=%d, want %d: %s", status, ExitCodeOK, errOut)
+	}
+	if !strings.Contains(inputs[0], "+func main() {}") || !strings.Contains(inputs[0], "+Usage") {
+		t.Errorf("expected the diffs, got %q", inputs[0])
+	}
+
+	if _, errOut, status, _, _ := runChangelog(t, "v1.1.0..HEAD"); status != ExitCodeFail || !strings.Contains(errOut, "no commits in v1.1.0..HEAD") {
+		t.Errorf("expected an error without commits, got %d: %q", status, errOut)
+	}
+
+	// A range is not taken for an option of git.
+	if _, _, status, _, _ := runChangelog(t, "--", "--output=leak"); status != ExitCodeFail {
+		t.Errorf("expected an error for an option as the range, got %d", status)
+	}
+	if leaked, _ := filepath.Glob("leak*"); len(leaked) != 0 {
+		t.Errorf("expected no file written by git, got %v", leaked)
+	}
+}
+
+func TestRun_changelogBatches(t *testing.T) {
+	setupTags(t)
+
+	out, errOut, status, prompts, inputs := runChangelog(t, "-limit", "60", "v1.0.0..v1.1.0")
+	if status != ExitCodeOK {
+		t.Fatalf("ExitStatus=%d, want %d: %s", status, ExitCodeOK, errOut)
+	}
+	if out != "### Features\n- merged\n" {
+		t.Errorf("unexpected output %q", out)
+	}
+
+	// Each commit is a batch, and the notes of the batches are merged in the last request.
+	if len(inputs) != 4 {
+		t.Fatalf("expected three batches and a merge, got %d requests: %q", len(inputs), inputs)
+	}
+	for i, message := range []string{"feat: add the entry point", "fix: define main", "docs: describe the usage"} {
+		if !strings.Contains(inputs[i], message) {
+			t.Errorf("batch %d=%q, want %q", i, inputs[i], message)
+		}
+	}
+	if want := "### Features\n- part\n\n---\n\n### Features\n- part\n\n---\n\n### Features\n- part"; inputs[3] != want {
+		t.Errorf("merge input=%q, want %q", inputs[3], want)
+	}
+	if !strings.HasPrefix(prompts[3], "The following release notes") {
+		t.Errorf("unexpected merge prompt %q", prompts[3])
+	}
+}
+
+func TestRun_changelogErrors(t *testing.T) {
+	input := setupConfig(t, "", "")
+
+	tests := [][]string{
+		{},
+		{"v1.0.0", "v1.1.0"},
+		{"-commit", "v1.0.0"},
+		{"-file", input, "v1.0.0"},
+	}
+	for _, args := range tests {
+		if _, _, status := runBento(t, &MockTranslator{}, env{terminal: true}, append([]string{"changelog"}, args...)...); status != ExitCodeFail {
+			t.Errorf("%v: ExitStatus=%d, want %d", args, status, ExitCodeFail)
+		}
+	}
+
+	if _, _, status := runBento(t, &MockTranslator{}, env{terminal: true}, "-diffs", "-file", input); status != ExitCodeFail {
+		t.Errorf("-diffs: ExitStatus=%d, want %d", status, ExitCodeFail)
+	}
+}
@@ -0,0 +1,260 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/catatsuy/bento/internal/markdown"
+)
+
+// DefaultChunkBy is the default chunker of multi mode.
+const DefaultChunkBy = "line"
+
+// chunker splits the input of multi mode into chunks.
+type chunker struct {
+	// segment splits text into segments that are kept in the same chunk if possible.
+	segment func(text string) []string
+	// measure returns the size of s counted against the limit.
+	measure func(s string) int
+}
+
+// newChunker returns the chunker named by -chunk-by.
+func newChunker(name string) (*chunker, error) {
+	runes := utf8.RuneCountInString
+	switch name {
+	case "line":
+		return &chunker{segment: splitLines, measure: runes}, nil
+	case "paragraph":
+		return &chunker{segment: splitParagraphs, measure: runes}, nil
+	case "markdown":
+		return &chunker{segment: splitMarkdown, measure: runes}, nil
+	case "sentence":
+		return &chunker{segment: splitSentences, measure: runes}, nil
+	case "tokens":
+		return &chunker{segment: splitLines, measure: estimateTokens}, nil
+	default:
+		return nil, fmt.Errorf("unknown chunker %q. Use line, paragraph, markdown, sentence or tokens", name)
+	}
+}
+
+// chunk reads r and calls fn with chunks of at most limit.
+// Segments are packed into a chunk as long as they fit. A segment larger than
+// the limit is split at lines, then at words and then at characters.
+func (ch *chunker) chunk(r io.Reader, limit int, fn func(chunk string) error) error {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("error reading input: %w", err)
+	}
+
+	var buf strings.Builder
+	size := 0
+	flush := func() error {
+		if buf.Len() == 0 {
+			return nil
+		}
+		chunk := buf.String()
+		buf.Reset()
+		size = 0
+		return fn(chunk)
+	}
+	add := func(s string) error {
+		n := ch.measure(s)
+		if size > 0 && size+n > limit {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+		buf.WriteString(s)
+		size += n
+		return nil
+	}
+
+	for _, seg := range ch.segment(string(b)) {
+		if ch.measure(seg) <= limit {
+			if err := add(seg); err != nil {
+				return err
+			}
+			continue
+		}
+
+		// An oversized segment does not share its chunks with its neighbors.
+		if err := flush(); err != nil {
+			return err
+		}
+		for _, piece := range splitOversized(seg, limit, ch.measure) {
+			if err := add(piece); err != nil {
+				return err
+			}
+		}
+		if err := flush(); err != nil {
+			return err
+		}
+	}
+	return flush()
+}
+
+// splitOversized splits s into pieces of at most limit, preferring line and then word boundaries.
+func splitOversized(s string, limit int, measure func(string) int) []string {
+	var pieces []string
+	for _, line := range splitLines(s) {
+		if measure(line) <= limit {
+			pieces = append(pieces, line)
+			continue
+		}
+		for _, word := range splitWords(line) {
+			if measure(word) <= limit {
+				pieces = append(pieces, word)
+				continue
+			}
+			for _, r := range word {
+				pieces = append(pieces, string(r))
+			}
+		}
+	}
+	return pieces
+}
+
+// splitLines splits text after each newline.
+func splitLines(text string) []string {
+	return markdown.SplitLines(text)
+}
+
+// splitWords splits s after each run of white space.
+func splitWords(s string) []string {
+	var words []string
+	start := 0
+	inSpace := false
+	for i, r := range s {
+		space := unicode.IsSpace(r)
+		if inSpace && !space {
+			words = append(words, s[start:i])
+			start = i
+		}
+		inSpace = space
+	}
+	if start < len(s) {
+		words = append(words, s[start:])
+	}
+	return words
+}
+
+// isBlank reports whether line consists of white space only.
+func isBlank(line string) bool {
+	return strings.TrimSpace(line) == ""
+}
+
+// splitParagraphs splits text into paragraphs, each followed by its trailing blank lines.
+func splitParagraphs(text string) []string {
+	var paragraphs []string
+	var b strings.Builder
+	for _, line := range splitLines(text) {
+		if !isBlank(line) && endsParagraph(b.String()) {
+			paragraphs = append(paragraphs, b.String())
+			b.Reset()
+		}
+		b.WriteString(line)
+	}
+	if b.Len() > 0 {
+		paragraphs = append(paragraphs, b.String())
+	}
+	return paragraphs
+}
+
+// endsParagraph reports whether s is a paragraph followed by a blank line.
+func endsParagraph(s string) bool {
+	if isBlank(s) {
+		return false
+	}
+	i := strings.LastIndexByte(strings.TrimSuffix(s, "\n"), '\n')
+	return isBlank(s[i+1:])
+}
+
+// splitMarkdown splits a Markdown document into blocks. Fenced code blocks are
+// a single block even if they contain blank lines, and a heading is kept in the
+// same block as the content following it.
+func splitMarkdown(text string) []string {
+	var blocks []string
+	var b strings.Builder
+	var fence string
+	heading := false
+
+	for _, line := range splitLines(text) {
+		if fence != "" {
+			b.WriteString(line)
+			if markdown.IsFenceEnd(line, fence) {
+				fence = ""
+			}
+			continue
+		}
+
+		// A non-blank line after a blank line starts a new block unless it follows a heading.
+		startsBlock := !isBlank(line) && endsParagraph(b.String())
+		if markdown.IsHeading(line) && !isBlank(b.String()) && !heading {
+			startsBlock = true
+		}
+		if startsBlock && !heading {
+			blocks = append(blocks, b.String())
+			b.Reset()
+		}
+		b.WriteString(line)
+
+		if !isBlank(line) {
+			heading = markdown.IsHeading(line)
+		}
+		if f := markdown.FenceStart(line); f != "" {
+			fence = f
+		}
+	}
+	if b.Len() > 0 {
+		blocks = append(blocks, b.String())
+	}
+	return blocks
+}
+
+// splitSentences splits text after each sentence terminator and the white space following it.
+// A blank line also ends a sentence.
+func splitSentences(text string) []string {
+	var sentences []string
+	rs := []rune(text)
+	start := 0
+	for i := 0; i < len(rs); i++ {
+		end := false
+		switch rs[i] {
+		case '。', '！', '？':
+			end = true
+		case '.', '!', '?':
+			end = i+1 == len(rs) || unicode.IsSpace(rs[i+1])
+		case '\n':
+			end = i+1 < len(rs) && rs[i+1] == '\n'
+		}
+		if !end {
+			continue
+		}
+		for i+1 < len(rs) && unicode.IsSpace(rs[i+1]) {
+			i++
+		}
+		sentences = append(sentences, string(rs[start:i+1]))
+		start = i + 1
+	}
+	if start < len(rs) {
+		sentences = append(sentences, string(rs[start:]))
+	}
+	return sentences
+}
+
+// estimateTokens estimates the number of tokens of s: about four characters
+// per token for ASCII text and one token per character otherwise.
+func estimateTokens(s string) int {
+	ascii, other := 0, 0
+	for _, r := range s {
+		if r < utf8.RuneSelf {
+			ascii++
+		} else {
+			other++
+		}
+	}
+	return (ascii+3)/4 + other
+}
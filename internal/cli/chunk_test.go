@@ -0,0 +1,161 @@
+package cli_test
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/catatsuy/bento/internal/cli"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestChunks(t *testing.T) {
+	markdown := "# Title\n\nIntro.\n\n## Code\n\n```go\nfunc main() {\n\n\tprintln()\n}\n```\n\n| a | b |\n|---|---|\n| 1 | 2 |\n"
+
+	tests := []struct {
+		name     string
+		chunkBy  string
+		text     string
+		limit    int
+		expected []string
+	}{
+		{
+			name:     "line packs lines",
+			chunkBy:  "line",
+			text:     "aaa\nbbb\nccc\n",
+			limit:    8,
+			expected: []string{"aaa\nbbb\n", "ccc\n"},
+		},
+		{
+			name:     "line keeps the last line without a newline",
+			chunkBy:  "line",
+			text:     "aaa\nbbb",
+			limit:    4,
+			expected: []string{"aaa\n", "bbb"},
+		},
+		{
+			name:     "line counts characters",
+			chunkBy:  "line",
+			text:     "あいう\nえお\n",
+			limit:    7,
+			expected: []string{"あいう\nえお\n"},
+		},
+		{
+			name:     "long line is split at words",
+			chunkBy:  "line",
+			text:     "short\nthe quick brown fox\nend\n",
+			limit:    10,
+			expected: []string{"short\n", "the quick ", "brown fox\n", "end\n"},
+		},
+		{
+			name:     "long word is split at characters",
+			chunkBy:  "line",
+			text:     "あいうえおかきくけこ\n",
+			limit:    4,
+			expected: []string{"あいうえ", "おかきく", "けこ\n"},
+		},
+		{
+			name:     "paragraph",
+			chunkBy:  "paragraph",
+			text:     "a a\nb b\n\nc c\n\n\nd d\n",
+			limit:    10,
+			expected: []string{"a a\nb b\n\n", "c c\n\n\nd d\n"},
+		},
+		{
+			name:     "paragraph with leading blank lines",
+			chunkBy:  "paragraph",
+			text:     "\n\naaaa\n\nbbbb\n",
+			limit:    8,
+			expected: []string{"\n\naaaa\n\n", "bbbb\n"},
+		},
+		{
+			name:    "markdown keeps fenced blocks and headings with their content",
+			chunkBy: "markdown",
+			text:    markdown,
+			limit:   60,
+			expected: []string{
+				"# Title\n\nIntro.\n\n",
+				"## Code\n\n```go\nfunc main() {\n\n\tprintln()\n}\n```\n\n",
+				"| a | b |\n|---|---|\n| 1 | 2 |\n",
+			},
+		},
+		{
+			name:     "markdown does not treat a heading in a fence as a heading",
+			chunkBy:  "markdown",
+			text:     "text\n\n~~~\n# comment\n~~~\n",
+			limit:    20,
+			expected: []string{"text\n\n", "~~~\n# comment\n~~~\n"},
+		},
+		{
+			name:     "sentence",
+			chunkBy:  "sentence",
+			text:     "One. Two? Three!\nこんにちは。さようなら。",
+			limit:    10,
+			expected: []string{"One. Two? ", "Three!\n", "こんにちは。", "さようなら。"},
+		},
+		{
+			name:     "sentence does not split decimals",
+			chunkBy:  "sentence",
+			text:     "Pi is 3.14. Yes.",
+			limit:    12,
+			expected: []string{"Pi is 3.14. ", "Yes."},
+		},
+		{
+			name:     "tokens",
+			chunkBy:  "tokens",
+			text:     "abcdefgh\nabcdefgh\nabcdefgh\n",
+			limit:    6,
+			expected: []string{"abcdefgh\nabcdefgh\n", "abcdefgh\n"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chunks, err := Chunks(tt.chunkBy, tt.text, tt.limit)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tt.expected, chunks); diff != "" {
+				t.Errorf("chunks mismatch (-expected +actual):\n%s", diff)
+			}
+			if strings.Join(chunks, "") != tt.text {
+				t.Errorf("chunks do not add up to the text: %q", chunks)
+			}
+		})
+	}
+}
+
+func TestChunks_unknown(t *testing.T) {
+	_, err := Chunks("word", "text", 10)
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
+
+func TestEstimateTokens(t *testing.T) {
+	tests := map[string]int{
+		"":         0,
+		"abcd":     1,
+		"abcde":    2,
+		"こんにちは":    5,
+		"hi こんにちは": 6,
+	}
+	for s, expected := range tests {
+		if got := EstimateTokens(s); got != expected {
+			t.Errorf("EstimateTokens(%q)=%d, want %d", s, got, expected)
+		}
+	}
+}
+
+func TestRun_chunkBy(t *testing.T) {
+	m := &mockModel{respond: func(r request) string { return strings.TrimSpace(r.input) }}
+	mustRunBento(t, m.translator(), env{stdin: "first paragraph\n\nsecond paragraph\n"}, "-multi", "-prompt", "x", "-chunk-by", "paragraph", "-limit", "20")
+
+	var inputs []string
+	for _, r := range m.calls() {
+		inputs = append(inputs, r.input)
+	}
+	expected := []string{"first paragraph\n\n", "second paragraph\n"}
+	if diff := cmp.Diff(expected, inputs); diff != "" {
+		t.Errorf("inputs mismatch (-expected +actual):\n%s", diff)
+	}
+}
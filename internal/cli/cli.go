@@ -1,30 +1,45 @@
 package cli
 
 import (
-	"bufio"
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
+	"maps"
 	"os"
 	"os/signal"
 	"runtime"
 	"runtime/debug"
+	"slices"
 	"strings"
 	"syscall"
+	"time"
 
+	"github.com/catatsuy/bento/internal/anthropic"
+	"github.com/catatsuy/bento/internal/apierror"
+	"github.com/catatsuy/bento/internal/commitmsg"
 	"github.com/catatsuy/bento/internal/gemini"
 	"github.com/catatsuy/bento/internal/openai"
+	"github.com/catatsuy/bento/internal/retry"
+	"golang.org/x/term"
 )
 
 const (
 	ExitCodeOK   = 0
 	ExitCodeFail = 1
 
+	// Exit codes for API errors the user can act on.
+	ExitCodeAuth          = 3
+	ExitCodeRateLimit     = 4
+	ExitCodeContextLength = 5
+	ExitCodeContentFilter = 6
+
 	DefaultExceedThreshold = 4000
 
-	DefaultOpenAIModel = "gpt-5-nano"
-	DefaultGeminiModel = "gemini-2.0-flash-lite"
+	DefaultOpenAIModel    = "gpt-5-nano"
+	DefaultGeminiModel    = "gemini-2.0-flash-lite"
+	DefaultAnthropicModel = "claude-haiku-4-5"
 )
 
 var (
@@ -37,6 +52,8 @@ type CLI struct {
 	inputStream          io.Reader
 
 	isStdinTerminal bool
+	// isStdoutTerminal enables the picker of -n.
+	isStdoutTerminal bool
 
 	appVersion string
 
@@ -48,20 +65,54 @@ type Translator interface {
 	request(ctx context.Context, systemPrompt, prompt, input, model string) (string, error)
 }
 
+// streamTranslator is implemented by Translators that can stream the response as it is generated.
+type streamTranslator interface {
+	requestStream(ctx context.Context, systemPrompt, prompt, input, model string, w io.Writer) error
+}
+
+// streamOrWrite streams the response of tr to w if tr can stream, and otherwise requests it
+// and writes it to w at once. It returns the whole response.
+func streamOrWrite(ctx context.Context, tr Translator, systemPrompt, prompt, input, model string, w io.Writer) (string, error) {
+	st, ok := tr.(streamTranslator)
+	if !ok {
+		text, err := tr.request(ctx, systemPrompt, prompt, input, model)
+		if err != nil {
+			return "", err
+		}
+		_, err = io.WriteString(w, text)
+		return text, err
+	}
+	var b strings.Builder
+	if err := st.requestStream(ctx, systemPrompt, prompt, input, model, io.MultiWriter(w, &b)); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
 // NewCLI returns a new CLI instance.
 func NewCLI(outStream, errStream io.Writer, inputStream io.Reader, tr Translator, isStdinTerminal bool) *CLI {
+	var isStdoutTerminal bool
+	if f, ok := outStream.(*os.File); ok {
+		isStdoutTerminal = term.IsTerminal(int(f.Fd()))
+	}
 	return &CLI{
-		appVersion:      version(),
-		outStream:       outStream,
-		errStream:       errStream,
-		inputStream:     inputStream,
-		translator:      tr,
-		isStdinTerminal: isStdinTerminal,
+		appVersion:       version(),
+		outStream:        outStream,
+		errStream:        errStream,
+		inputStream:      inputStream,
+		translator:       tr,
+		isStdinTerminal:  isStdinTerminal,
+		isStdoutTerminal: isStdoutTerminal,
 	}
 }
 
 // Run parses CLI arguments and executes the appropriate functionality.
 func (c *CLI) Run(args []string) int {
+	return c.run(context.Background(), args)
+}
+
+// run is Run with a context. The prepare-commit-msg hook uses it to limit the time bento takes.
+func (c *CLI) run(ctx context.Context, args []string) int {
 	if len(args) <= 1 {
 		fmt.Fprintf(c.errStream, "Error: Insufficient arguments provided\n")
 		return ExitCodeFail
@@ -75,6 +126,9 @@ func (c *CLI) Run(args []string) int {
 		commitMessage    bool
 		translate        bool
 		review           bool
+		pullRequest      bool
+		catalogFile      string
+		catalogOpts      catalogOptions
 
 		language     string
 		prompt       string
@@ -89,11 +143,131 @@ func (c *CLI) Run(args []string) int {
 		isMultiMode  bool
 		isSingleMode bool
 
-		limit int
+		limit       int
+		chunkBy     string
+		isMarkdown  bool
+		isSubtitle  bool
+		concurrency int
+		rate        float64
 
 		backend string
+		baseURL string
+
+		stream    bool
+		showUsage bool
+
+		maxRetries    int
+		retryMaxDelay time.Duration
+
+		profile string
+
+		promptFile   string
+		systemFile   string
+		glossaryFile string
+		vars         = make(map[string]string)
+
+		noCache  bool
+		useCache bool
+		cacheTTL time.Duration
+		verbose  bool
+
+		baseRef  string
+		diffArgs string
+		apply    bool
+		count    int
+
+		commitStyleName string
+		nulSeparated    bool
+
+		prTemplateFile string
+		prJSON         bool
+
+		changelogRange string
+		withDiffs      bool
 	)
 
+	// The hook does not use the config files, and it must be possible to uninstall it
+	// even if they are broken.
+	if args[1] == "hook" {
+		return c.runHook(args[2:])
+	}
+
+	// Config files are loaded before parsing the flags because they provide the flag defaults.
+	configs, err := loadConfigFiles()
+	if err != nil {
+		// -version and -help work without the defaults of a broken config file.
+		if !hasFlag(args[1:], "version", "help", "h") {
+			fmt.Fprintf(c.errStream, "Error: %v\n", err)
+			return ExitCodeFail
+		}
+		fmt.Fprintf(c.errStream, "Warning: %v\n", err)
+		configs = &configFiles{user: &Config{}, repo: &Config{}}
+	}
+	if len(configs.ignored) > 0 {
+		fmt.Fprintf(c.errStream, "Warning: %s sets %s, which can only be set in the user config, environment variables or flags. It is ignored.\n", configs.repoPath, strings.Join(configs.ignored, ", "))
+	}
+	settings, err := configs.settings(lookupFlag(args, "profile"))
+	if err != nil {
+		fmt.Fprintf(c.errStream, "Error: %v\n", err)
+		return ExitCodeFail
+	}
+	if settings.Backend == "" {
+		settings.Backend = "openai"
+	}
+	if settings.Limit == 0 {
+		settings.Limit = DefaultExceedThreshold
+	}
+	if settings.CacheDir == "" {
+		settings.CacheDir = defaultCacheDir()
+	}
+
+	if args[1] == "cache" {
+		return c.runCache(args[2:], settings.CacheDir)
+	}
+	// "bento run <name>" runs a user-defined command whose values become the flag defaults.
+	flagArgs := args[1:]
+	var command *Command
+	if args[1] == "run" {
+		if len(args) < 3 || strings.HasPrefix(args[2], "-") {
+			c.printCommands(configs)
+			return ExitCodeOK
+		}
+		cmd, ok := configs.command(args[2])
+		if !ok {
+			fmt.Fprintf(c.errStream, "Error: Unknown command %q. Run 'bento run' to list the commands.\n", args[2])
+			return ExitCodeFail
+		}
+		command = &cmd
+		flagArgs = args[3:]
+
+		if cmd.System != "" {
+			settings.System = cmd.System
+		}
+		if cmd.Model != "" {
+			settings.Model = cmd.Model
+		}
+		if cmd.Limit != 0 {
+			settings.Limit = cmd.Limit
+		}
+		switch cmd.Mode {
+		case "", "single":
+			isSingleMode = true
+		case "multi":
+			isMultiMode = true
+		default:
+			fmt.Fprintf(c.errStream, "Error: Invalid mode %q for command %q. Use single or multi.\n", cmd.Mode, args[2])
+			return ExitCodeFail
+		}
+	}
+
+	// "bento translate-catalog <file>" translates the messages of an i18n catalog.
+	isCatalog := args[1] == "translate-catalog"
+	// "bento changelog <from>..<to>" writes the release notes of the commits between two refs.
+	isChangelog := args[1] == "changelog"
+	if isCatalog || isChangelog {
+		flagArgs = args[2:]
+	}
+
 	flags := flag.NewFlagSet("bento", flag.ContinueOnError)
 	flags.SetOutput(c.errStream)
 
@@ -107,22 +281,63 @@ func (c *CLI) Run(args []string) int {
 	flags.BoolVar(&commitMessage, "commit", false, "Suggest commit message")
 	flags.BoolVar(&translate, "translate", false, "Translate text")
 	flags.BoolVar(&review, "review", false, "Review source code")
+	flags.BoolVar(&pullRequest, "pr", false, "Suggest the title and description of a pull request from the commits and the diff of the current branch")
+
+	flags.StringVar(&catalogOpts.output, "output", "", "Write the translated catalog to a file, keeping its existing translations (translate-catalog)")
+	flags.StringVar(&catalogOpts.output, "o", "", "Write the translated catalog to a file, keeping its existing translations (translate-catalog)")
+	flags.StringVar(&catalogOpts.format, "format", "", "Format of the catalog: json, yaml or po (translate-catalog, detected from the extension by default)")
+
+	flags.StringVar(&baseRef, "base", "", "Use the changes since the branch forked from this ref, such as main (review and pr modes, the default branch of origin, main or master for pr)")
+	flags.StringVar(&diffArgs, "diff-args", "", "Extra arguments of git diff, separated by spaces, when bento runs git (branch, commit, review and pr modes)")
+	flags.StringVar(&prTemplateFile, "pr-template", "", "Pull request template the description follows (pr mode, .github/pull_request_template.md and the other locations GitHub uses by default)")
+	flags.BoolVar(&withDiffs, "diffs", false, "Send the diff of each commit with its message (changelog)")
+	flags.BoolVar(&prJSON, "json", false, "Write the pull request as a JSON object with title and body instead of Markdown (pr mode)")
+
+	flags.BoolVar(&apply, "apply", false, "Create the suggested branch or commit with the suggested message, after confirmation when standard input is a terminal (branch and commit modes)")
+
+	flags.StringVar(&commitStyleName, "commit-style", settings.CommitStyle, "Style of the commit message: plain, conventional, gitmoji or custom with -prompt, each with a subject line and a body (commit mode, a single line by default)")
+	flags.IntVar(&count, "n", 1, "Number of suggestions to request, chosen from interactively when standard output is a terminal (branch and commit modes). Otherwise they are written one per line, or separated by a line of --- between blank lines with -commit-style")
+	flags.BoolVar(&nulSeparated, "z", false, "Separate the suggestions of -n with NUL characters instead (branch and commit modes)")
 
 	flags.BoolVar(&dump, "dump", false, "Dump repository contents")
 	flags.StringVar(&description, "description", "", "Description of the repository (dump mode)")
 
-	flags.IntVar(&limit, "limit", DefaultExceedThreshold, "Limit the number of characters to translate")
+	flags.IntVar(&limit, "limit", settings.Limit, "Maximum size of a chunk in multi mode, in characters or in estimated tokens with -chunk-by tokens")
+	flags.StringVar(&chunkBy, "chunk-by", DefaultChunkBy, "Split the input of multi mode by line, paragraph, markdown, sentence or tokens")
+
+	flags.BoolVar(&isMultiMode, "multi", isMultiMode, "Multi mode")
+	flags.BoolVar(&isMarkdown, "markdown", false, "Send only the text of Markdown input, keeping code blocks, link targets, HTML and front matter unchanged (multi mode)")
+	flags.BoolVar(&isSubtitle, "subtitle", false, "Translate only the text of SRT or WebVTT subtitles, keeping cue numbers and timestamps (multi mode, the default for .srt and .vtt files)")
+	flags.IntVar(&concurrency, "concurrency", 1, "Number of chunks requested in parallel (multi mode)")
+	flags.Float64Var(&rate, "rate", 0, "Maximum number of requests per second (multi mode, 0 means no limit)")
+	flags.BoolVar(&isSingleMode, "single", isSingleMode, "Single mode (default)")
+	flags.BoolVar(&stream, "stream", false, "Write the response as it is generated (single mode)")
+	flags.BoolVar(&noCache, "no-cache", false, "Request every chunk instead of reusing the translations of unchanged chunks (multi mode)")
+	flags.BoolVar(&useCache, "cache", false, "Reuse the responses to identical requests made within -cache-ttl (single mode)")
+	flags.DurationVar(&cacheTTL, "cache-ttl", DefaultCacheTTL, "How long the responses cached with -cache are used (0 means forever)")
+	flags.BoolVar(&verbose, "verbose", false, "Print details such as the cache hits and misses to standard error")
+	flags.BoolVar(&showUsage, "usage", false, "Print the token usage and the estimated cost to standard error")
 
-	flags.BoolVar(&isMultiMode, "multi", false, "Multi mode")
-	flags.BoolVar(&isSingleMode, "single", false, "Single mode (default)")
+	flags.IntVar(&maxRetries, "max-retries", retry.DefaultMaxRetries, "Maximum number of retries on rate limits and server errors (0 disables retries)")
+	flags.DurationVar(&retryMaxDelay, "retry-max-delay", retry.DefaultMaxDelay, "Maximum delay between retries")
 
-	flags.StringVar(&language, "language", "", "Specify the output language")
-	flags.StringVar(&prompt, "prompt", "", "Prompt text")
-	flags.StringVar(&systemPrompt, "system", "", "System prompt text")
-	flags.StringVar(&useModel, "model", DefaultOpenAIModel, "Use models such as gpt-5-nano, gpt-5-mini, and gpt-5. (When using the gemini backend, the default model becomes "+DefaultGeminiModel+")")
-	flags.StringVar(&backend, "backend", "openai", "Backend to use: openai or gemini")
+	flags.StringVar(&language, "language", settings.Language, "Specify the output language")
+	var defaultPrompt string
+	if command != nil {
+		defaultPrompt = command.Prompt
+	}
+	flags.StringVar(&prompt, "prompt", defaultPrompt, "Prompt text")
+	flags.StringVar(&systemPrompt, "system", settings.System, "System prompt text")
+	flags.StringVar(&promptFile, "prompt-file", "", "Read the prompt text from a file")
+	flags.StringVar(&systemFile, "system-file", "", "Read the system prompt text from a file")
+	flags.StringVar(&glossaryFile, "glossary", "", "Tab-separated glossary file of source terms, target terms and optional notes; an empty target keeps the term as is")
+	flags.Func("var", "Set a template variable available as {{.Vars.key}} in the prompts (key=value, repeatable)", parseVar(vars))
+	flags.StringVar(&useModel, "model", settings.Model, "Use models such as gpt-5-nano, gpt-5-mini, and gpt-5. (The default is "+DefaultOpenAIModel+" for the openai backend, "+DefaultGeminiModel+" for the gemini backend and "+DefaultAnthropicModel+" for the anthropic backend)")
+	flags.StringVar(&backend, "backend", settings.Backend, "Backend to use: openai, gemini, anthropic or openai-compatible")
+	flags.StringVar(&baseURL, "base-url", settings.BaseURL, "Base URL of the API for the openai-compatible backend, e.g. http://localhost:11434/v1")
+	flags.StringVar(&profile, "profile", "", "Use the named profile of the config files")
 
-	err := flags.Parse(args[1:])
+	err = flags.Parse(flagArgs)
 	if err != nil {
 		fmt.Fprintf(c.errStream, "Error: %v\n", err)
 		return ExitCodeFail
@@ -140,9 +355,85 @@ func (c *CLI) Run(args []string) int {
 		return ExitCodeOK
 	}
 
-	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+	ctx, stop := signal.NotifyContext(ctx, syscall.SIGTERM, syscall.SIGINT)
 	defer stop()
 
+	// Escapes such as \n are unescaped in prompts given on the command line.
+	if isFlagSet(flags, "prompt") {
+		prompt = unescapeString(prompt)
+	}
+	if isFlagSet(flags, "system") {
+		systemPrompt = unescapeString(systemPrompt)
+	}
+
+	if promptFile != "" {
+		if isFlagSet(flags, "prompt") {
+			fmt.Fprintf(c.errStream, "Error: Both '-prompt' and '-prompt-file' cannot be specified simultaneously.\n")
+			return ExitCodeFail
+		}
+		b, err := os.ReadFile(promptFile)
+		if err != nil {
+			fmt.Fprintf(c.errStream, "Error: %v\n", err)
+			return ExitCodeFail
+		}
+		prompt = string(b)
+	}
+
+	if systemFile != "" {
+		if isFlagSet(flags, "system") {
+			fmt.Fprintf(c.errStream, "Error: Both '-system' and '-system-file' cannot be specified simultaneously.\n")
+			return ExitCodeFail
+		}
+		b, err := os.ReadFile(systemFile)
+		if err != nil {
+			fmt.Fprintf(c.errStream, "Error: %v\n", err)
+			return ExitCodeFail
+		}
+		systemPrompt = string(b)
+	}
+
+	if command != nil {
+		// The mode given on the command line overrides the mode of the command.
+		if isFlagSet(flags, "multi") && !isFlagSet(flags, "single") {
+			isSingleMode = false
+		} else if isFlagSet(flags, "single") && !isFlagSet(flags, "multi") {
+			isMultiMode = false
+		}
+		if branchSuggestion || commitMessage || translate || review || pullRequest || dump {
+			fmt.Fprintf(c.errStream, "Error: The built-in modes cannot be used with 'run'.\n")
+			return ExitCodeFail
+		}
+	}
+
+	if isCatalog {
+		if branchSuggestion || commitMessage || translate || review || pullRequest || dump || isMarkdown || isSubtitle {
+			fmt.Fprintf(c.errStream, "Error: The built-in modes cannot be used with 'translate-catalog'.\n")
+			return ExitCodeFail
+		}
+		if flags.NArg() != 1 {
+			fmt.Fprintf(c.errStream, "Error: Specify a catalog file: bento translate-catalog [flags] <file>\n")
+			return ExitCodeFail
+		}
+		catalogFile = flags.Arg(0)
+		isSingleMode = false
+		isMultiMode = true
+	} else if isChangelog {
+		if branchSuggestion || commitMessage || translate || review || pullRequest || dump || isMarkdown || isSubtitle {
+			fmt.Fprintf(c.errStream, "Error: The built-in modes cannot be used with 'changelog'.\n")
+			return ExitCodeFail
+		}
+		if flags.NArg() != 1 {
+			fmt.Fprintf(c.errStream, "Error: Specify a range of commits: bento changelog [flags] <from>..<to>\n")
+			return ExitCodeFail
+		}
+		changelogRange = flags.Arg(0)
+		isSingleMode = false
+		isMultiMode = false
+	} else if isFlagSet(flags, "output") || isFlagSet(flags, "o") || isFlagSet(flags, "format") {
+		fmt.Fprintf(c.errStream, "Error: The '-output' and '-format' options can only be used with 'translate-catalog'.\n")
+		return ExitCodeFail
+	}
+
 	if isSingleMode && isMultiMode {
 		fmt.Fprintf(c.errStream, "Error: Both 'multi' and 'single' modes cannot be specified simultaneously.\n")
 		return ExitCodeFail
@@ -153,13 +444,42 @@ func (c *CLI) Run(args []string) int {
 		isSingleMode = true
 	}
 
-	if (!translate && !review) && language != "" {
-		fmt.Fprintf(c.errStream, "Error: The '-language' option can only be used with '-translate' or '-review'.\n")
+	// Markdown mode splits the text at Markdown blocks unless -chunk-by is given.
+	if isMarkdown && !isFlagSet(flags, "chunk-by") {
+		chunkBy = "markdown"
+	}
+	chunker, err := newChunker(chunkBy)
+	if err != nil {
+		fmt.Fprintf(c.errStream, "Error: %v\n", err)
+		return ExitCodeFail
+	}
+
+	if limit < 1 {
+		fmt.Fprintf(c.errStream, "Error: The '-limit' option must be at least 1.\n")
+		return ExitCodeFail
+	}
+
+	if concurrency < 1 {
+		fmt.Fprintf(c.errStream, "Error: The '-concurrency' option must be at least 1.\n")
+		return ExitCodeFail
+	}
+
+	if rate < 0 {
+		fmt.Fprintf(c.errStream, "Error: The '-rate' option must not be negative.\n")
+		return ExitCodeFail
+	}
+
+	// The language may also come from the config files, where it only applies to the modes using it.
+	// A custom prompt can refer to the language as {{.Language}}.
+	if (!translate && !review && !pullRequest && !isCatalog && !isChangelog && prompt == "") && isFlagSet(flags, "language") {
+		fmt.Fprintf(c.errStream, "Error: The '-language' option can only be used with '-translate', '-review', '-pr', 'translate-catalog', 'changelog' or a custom prompt.\n")
 		return ExitCodeFail
 	}
 
 	// If not in dump mode, ensure a translator is set.
 	if !dump {
+		policy := retry.NewPolicy(maxRetries)
+		policy.MaxDelay = retryMaxDelay
 		if c.translator == nil {
 			// Choose translator based on the backend flag.
 			switch strings.ToLower(backend) {
@@ -169,27 +489,64 @@ func (c *CLI) Run(args []string) int {
 					fmt.Fprintln(c.errStream, "Error: You need to set GEMINI_API_KEY")
 					return ExitCodeFail
 				}
-				if useModel == DefaultOpenAIModel {
+				if useModel == "" {
 					useModel = DefaultGeminiModel
 				}
-				gt, err := NewGeminiTranslator(apiKey)
+				gt, err := NewGeminiTranslator(apiKey, policy)
 				if err != nil {
 					fmt.Fprintf(c.errStream, "Error creating Gemini translator: %v\n", err)
 					return ExitCodeFail
 				}
 				c.translator = gt
-			default:
+			case "anthropic":
+				apiKey := os.Getenv("ANTHROPIC_API_KEY")
+				if apiKey == "" {
+					fmt.Fprintln(c.errStream, "Error: You need to set ANTHROPIC_API_KEY")
+					return ExitCodeFail
+				}
+				if useModel == "" {
+					useModel = DefaultAnthropicModel
+				}
+				at, err := NewAnthropicTranslator(apiKey, policy)
+				if err != nil {
+					fmt.Fprintf(c.errStream, "Error creating Anthropic translator: %v\n", err)
+					return ExitCodeFail
+				}
+				c.translator = at
+			case "openai-compatible":
+				if baseURL == "" {
+					fmt.Fprintln(c.errStream, "Error: You need to set -base-url or BENTO_BASE_URL for the openai-compatible backend")
+					return ExitCodeFail
+				}
+				if useModel == "" {
+					fmt.Fprintln(c.errStream, "Error: The '-model' option is required for the openai-compatible backend")
+					return ExitCodeFail
+				}
+				// The API key is optional because local servers usually do not require one.
+				ct, err := NewOpenAICompatibleTranslator(baseURL, os.Getenv("BENTO_API_KEY"), policy)
+				if err != nil {
+					fmt.Fprintf(c.errStream, "Error creating OpenAI-compatible translator: %v\n", err)
+					return ExitCodeFail
+				}
+				c.translator = ct
+			case "openai":
 				apiKey := os.Getenv("OPENAI_API_KEY")
 				if apiKey == "" {
 					fmt.Fprintln(c.errStream, "Error: You need to set OPENAI_API_KEY")
 					return ExitCodeFail
 				}
-				ot, err := NewOpenAITranslator(apiKey)
+				if useModel == "" {
+					useModel = DefaultOpenAIModel
+				}
+				ot, err := NewOpenAITranslator(apiKey, policy)
 				if err != nil {
 					fmt.Fprintf(c.errStream, "Error creating OpenAI translator: %v\n", err)
 					return ExitCodeFail
 				}
 				c.translator = ot
+			default:
+				fmt.Fprintf(c.errStream, "Error: Unknown backend %q. Use openai, gemini, anthropic or openai-compatible.\n", backend)
+				return ExitCodeFail
 			}
 		}
 	}
@@ -213,12 +570,120 @@ func (c *CLI) Run(args []string) int {
 		return ExitCodeOK
 	}
 
-	if c.isStdinTerminal && targetFile == "" {
+	if isCatalog && targetFile != "" {
+		fmt.Fprintf(c.errStream, "Error: The '-file' option cannot be used with 'translate-catalog'.\n")
+		return ExitCodeFail
+	}
+	if isChangelog && targetFile != "" {
+		fmt.Fprintf(c.errStream, "Error: The '-file' option cannot be used with 'changelog'.\n")
+		return ExitCodeFail
+	}
+	if withDiffs && !isChangelog {
+		fmt.Fprintf(c.errStream, "Error: The '-diffs' option can only be used with 'changelog'.\n")
+		return ExitCodeFail
+	}
+
+	if apply && !branchSuggestion && !commitMessage {
+		fmt.Fprintf(c.errStream, "Error: The '-apply' option can only be used with '-branch' or '-commit'.\n")
+		return ExitCodeFail
+	}
+	commitStyle, err := commitmsg.ParseStyle(commitStyleName)
+	if err != nil {
+		fmt.Fprintf(c.errStream, "Error: Invalid commit style %q. Use plain, conventional, gitmoji or custom.\n", commitStyleName)
+		return ExitCodeFail
+	}
+	if isFlagSet(flags, "commit-style") && !commitMessage {
+		fmt.Fprintf(c.errStream, "Error: The '-commit-style' option can only be used with '-commit'.\n")
+		return ExitCodeFail
+	}
+
+	if count < 1 {
+		fmt.Fprintf(c.errStream, "Error: The '-n' option must be at least 1.\n")
+		return ExitCodeFail
+	}
+	if isFlagSet(flags, "n") && !branchSuggestion && !commitMessage {
+		fmt.Fprintf(c.errStream, "Error: The '-n' option can only be used with '-branch' or '-commit'.\n")
+		return ExitCodeFail
+	}
+	if nulSeparated && !isFlagSet(flags, "n") {
+		fmt.Fprintf(c.errStream, "Error: The '-z' option can only be used with '-n'.\n")
+		return ExitCodeFail
+	}
+	if count > 1 && stream {
+		fmt.Fprintf(c.errStream, "Error: The '-stream' option cannot be used with '-n'.\n")
+		return ExitCodeFail
+	}
+	if count > 1 && apply && !c.canPick() {
+		fmt.Fprintf(c.errStream, "Error: The '-apply' option can only be used with '-n' when standard input and output are terminals to choose the suggestion.\n")
+		return ExitCodeFail
+	}
+	// The answers to the questions of -apply are read from the terminal.
+	var terminal io.Reader
+	if c.isStdinTerminal {
+		terminal = c.inputStream
+	}
+
+	if (prTemplateFile != "" || prJSON) && !pullRequest {
+		fmt.Fprintf(c.errStream, "Error: The '-pr-template' and '-json' options can only be used with '-pr'.\n")
+		return ExitCodeFail
+	}
+	if pullRequest && stream {
+		fmt.Fprintf(c.errStream, "Error: The '-stream' option cannot be used with '-pr'.\n")
+		return ExitCodeFail
+	}
+
+	// -branch, -commit, -review and -pr run git themselves unless the diff is piped in or given with -file.
+	usesGit := (branchSuggestion || commitMessage || review || pullRequest) && c.isStdinTerminal && targetFile == ""
+	if baseRef != "" && !review && !pullRequest {
+		fmt.Fprintf(c.errStream, "Error: The '-base' option can only be used with '-review' or '-pr'.\n")
+		return ExitCodeFail
+	}
+	if (baseRef != "" || diffArgs != "") && !usesGit {
+		fmt.Fprintf(c.errStream, "Error: The '-base' and '-diff-args' options can only be used when bento runs git diff, without input on standard input or '-file'.\n")
+		return ExitCodeFail
+	}
+	if usesGit {
+		extra := strings.Fields(diffArgs)
+		var diff string
+		switch {
+		case commitMessage:
+			diff, err = stagedDiff(ctx, extra)
+		case pullRequest:
+			if baseRef == "" {
+				baseRef, err = defaultBase(ctx)
+			}
+			if err == nil {
+				diff, err = prInput(ctx, baseRef, extra)
+			}
+		case review && baseRef != "":
+			diff, err = baseDiff(ctx, baseRef, extra)
+		default:
+			diff, err = workingTreeDiff(ctx, extra)
+		}
+		if err != nil {
+			fmt.Fprintf(c.errStream, "Error: %v\n", err)
+			return ExitCodeFail
+		}
+		if strings.TrimSpace(diff) == "" {
+			switch {
+			case commitMessage:
+				fmt.Fprintf(c.errStream, "Error: There are no staged changes. Stage the changes with 'git add' first.\n")
+			case pullRequest:
+				fmt.Fprintf(c.errStream, "Error: There are no commits between %s and HEAD.\n", baseRef)
+			default:
+				fmt.Fprintf(c.errStream, "Error: There are no changes. Use 'git add -N' to include new files.\n")
+			}
+			return ExitCodeFail
+		}
+		c.inputStream = strings.NewReader(diff)
+	}
+
+	if c.isStdinTerminal && targetFile == "" && !isCatalog && !isChangelog && !usesGit {
 		fmt.Fprintf(c.errStream, "Error: The '-file' option is required when reading from standard input.\n")
 		return ExitCodeFail
 	}
 
-	if !c.isStdinTerminal && targetFile != "" {
+	if !c.isStdinTerminal && targetFile != "" && !isCatalog && !isChangelog {
 		fmt.Fprintf(c.errStream, "Error: The '-file' option cannot be used when reading from a file.\n")
 		return ExitCodeFail
 	}
@@ -230,7 +695,12 @@ func (c *CLI) Run(args []string) int {
 	} else if commitMessage {
 		isSingleMode = true
 		isMultiMode = false
-		prompt = "Generate a commit message directly from the provided source code differences without any additional text or formatting within 72 characters:\n\n"
+		if commitStyle != commitmsg.Custom {
+			prompt = commitPrompts[commitStyle]
+		} else if prompt == "" {
+			fmt.Fprintf(c.errStream, "Error: The custom commit style needs a prompt given with '-prompt' or '-prompt-file'.\n")
+			return ExitCodeFail
+		}
 	} else if translate {
 		if language == "" {
 			language = "en"
@@ -238,6 +708,17 @@ func (c *CLI) Run(args []string) int {
 		isMultiMode = true
 		isSingleMode = false
 		prompt = "Translate the following text to " + language + " without any additional text or formatting:\n\n"
+	} else if isCatalog {
+		if language == "" {
+			language = "en"
+		}
+		if prompt == "" {
+			prompt = catalogPrompt(language)
+		}
+	} else if isChangelog {
+		if prompt == "" {
+			prompt = changelogPrompt(language)
+		}
 	} else if review {
 		isSingleMode = true
 		isMultiMode = false
@@ -250,6 +731,119 @@ If you find a problem, briefly explain the issue and provide a specific suggesti
 		}
 
 		prompt += "\n\n"
+	} else if pullRequest {
+		isSingleMode = true
+		isMultiMode = false
+		template := ""
+		if prTemplateFile != "" {
+			b, err := os.ReadFile(prTemplateFile)
+			if err != nil {
+				fmt.Fprintf(c.errStream, "Error: %v\n", err)
+				return ExitCodeFail
+			}
+			template = string(b)
+		} else {
+			template, err = findPRTemplate(ctx)
+			if err != nil {
+				fmt.Fprintf(c.errStream, "Error: %v\n", err)
+				return ExitCodeFail
+			}
+		}
+		prompt = prPrompt(template, language)
+	}
+
+	if isMarkdown && !isMultiMode {
+		fmt.Fprintf(c.errStream, "Error: The '-markdown' option can only be used in multi mode.\n")
+		return ExitCodeFail
+	}
+
+	// Subtitle files are translated cue by cue in multi mode unless another mode is asked for.
+	if isMultiMode && !isMarkdown && !isFlagSet(flags, "subtitle") && !isFlagSet(flags, "chunk-by") && isSubtitleFile(targetFile) {
+		isSubtitle = true
+	}
+	if isSubtitle && !isMultiMode {
+		fmt.Fprintf(c.errStream, "Error: The '-subtitle' option can only be used in multi mode.\n")
+		return ExitCodeFail
+	}
+	if isSubtitle && isMarkdown {
+		fmt.Fprintf(c.errStream, "Error: Both '-subtitle' and '-markdown' cannot be specified simultaneously.\n")
+		return ExitCodeFail
+	}
+
+	if useCache {
+		if !isSingleMode {
+			fmt.Fprintf(c.errStream, "Error: The '-cache' option can only be used in single mode. Multi mode reuses the translations of unchanged chunks unless '-no-cache' is given.\n")
+			return ExitCodeFail
+		}
+		if settings.CacheDir == "" {
+			fmt.Fprintf(c.errStream, "Error: The cache directory is unknown. Set cache_dir or BENTO_CACHE_DIR.\n")
+			return ExitCodeFail
+		}
+		if cacheTTL < 0 {
+			fmt.Fprintf(c.errStream, "Error: The '-cache-ttl' option must not be negative.\n")
+			return ExitCodeFail
+		}
+		var verboseStream io.Writer
+		if verbose {
+			verboseStream = c.errStream
+		}
+		// The base URL tells the servers of the openai-compatible backend apart.
+		c.translator = newCacheTranslator(c.translator, settings.CacheDir, strings.ToLower(backend)+" "+baseURL, cacheTTL, verboseStream)
+	}
+
+	// memoryContext is what shapes the prompts sent besides the prompts themselves.
+	// As with -cache, the base URL tells the servers of the openai-compatible backend apart.
+	memoryContext := []string{strings.ToLower(backend) + " " + baseURL, targetFile}
+	if glossaryFile != "" {
+		entries, err := loadGlossary(glossaryFile)
+		if err != nil {
+			fmt.Fprintf(c.errStream, "Error: %v\n", err)
+			return ExitCodeFail
+		}
+		c.translator = newGlossaryTranslator(c.translator, entries, c.errStream)
+		memoryContext = append(memoryContext, fmt.Sprint(entries))
+	}
+	for _, k := range slices.Sorted(maps.Keys(vars)) {
+		memoryContext = append(memoryContext, k+"="+vars[k])
+	}
+
+	// Prompts are text/template templates.
+	data := PromptData{
+		Language: language,
+		FileName: targetFile,
+		Vars:     vars,
+	}
+	c.translator = newTemplateTranslator(c.translator, data, func() string { return currentBranch(ctx) })
+
+	if showUsage {
+		defer c.printUsage(useModel)
+	}
+
+	opts := multiOptions{
+		chunker:     chunker,
+		limit:       limit,
+		concurrency: concurrency,
+		rate:        rate,
+	}
+	if !noCache && settings.CacheDir != "" {
+		if usesBranch(systemPrompt, prompt) {
+			memoryContext = append(memoryContext, "branch="+currentBranch(ctx))
+		}
+		opts.memory = newTranslationMemory(settings.CacheDir, language, useModel, strings.Join(memoryContext, "\x00"), c.errStream)
+	}
+
+	if isCatalog {
+		if err := c.translateCatalog(ctx, systemPrompt, prompt, useModel, opts, catalogFile, catalogOpts); err != nil {
+			return c.requestError(err)
+		}
+		return ExitCodeOK
+	}
+
+	if isChangelog {
+		if err := c.changelog(ctx, systemPrompt, prompt, language, useModel, opts, changelogRange, withDiffs); err != nil {
+			return c.requestError(err)
+		}
+		return ExitCodeOK
 	}
 
 	if targetFile != "" {
@@ -269,20 +863,82 @@ If you find a problem, briefly explain the issue and provide a specific suggesti
 			return ExitCodeFail
 		}
 
-		suggestion, err := c.translator.request(ctx, systemPrompt, prompt, string(content), useModel)
+		// check formats a suggested commit message and makes sure that it follows the commit style.
+		check := func(ctx context.Context, suggestion string) (string, error) {
+			if !commitMessage {
+				return suggestion, nil
+			}
+			return c.checkCommitMessage(ctx, commitStyle, systemPrompt, prompt, string(content), useModel, suggestion)
+		}
+
+		if pullRequest {
+			response, err := c.translator.request(ctx, systemPrompt, prompt, string(content), useModel)
+			if err != nil {
+				return c.requestError(err)
+			}
+			if err := writePullRequest(c.outStream, parsePullRequest(response), prJSON); err != nil {
+				fmt.Fprintf(c.errStream, "Error: %v\n", err)
+				return ExitCodeFail
+			}
+			return ExitCodeOK
+		}
+
+		if count > 1 {
+			sep := candidateSeparator(nulSeparated, commitMessage && commitStyle != commitmsg.Subject)
+			return c.runCandidates(ctx, systemPrompt, prompt, string(content), useModel, count, check, sep, commitMessage, apply, terminal)
+		}
+
+		// generate writes the response and returns it for -apply.
+		generate := func(ctx context.Context) (string, error) {
+			if st, ok := c.translator.(streamTranslator); ok && stream {
+				var b strings.Builder
+				err := st.requestStream(ctx, systemPrompt, prompt, string(content), useModel, io.MultiWriter(c.outStream, &b))
+				if err != nil {
+					return "", err
+				}
+				fmt.Fprintln(c.outStream)
+				// A streamed commit message cannot be requested again, so it is only checked.
+				if commitMessage {
+					if err := commitmsg.Validate(commitmsg.Format(commitMessageOf(b.String())), commitStyle); err != nil {
+						fmt.Fprintf(c.errStream, "Warning: The suggested commit message is invalid: %v.\n", err)
+					}
+				}
+				return b.String(), nil
+			}
+
+			suggestion, err := c.translator.request(ctx, systemPrompt, prompt, string(content), useModel)
+			if err != nil {
+				return "", err
+			}
+			suggestion, err = check(ctx, suggestion)
+			if err != nil {
+				return "", err
+			}
+			fmt.Fprintf(c.outStream, "%s\n", suggestion)
+			return suggestion, nil
+		}
+
+		suggestion, err := generate(ctx)
 		if err != nil {
-			fmt.Fprintf(c.errStream, "Error: %v\n", err)
-			return ExitCodeFail
+			return c.requestError(err)
+		}
+		if apply {
+			return c.apply(ctx, commitMessage, suggestion, generate, terminal)
 		}
-		fmt.Fprintf(c.outStream, "%s\n", suggestion)
 		return ExitCodeOK
 	}
 
 	if isMultiMode {
-		err = c.multiRequest(ctx, systemPrompt, prompt, useModel, limit)
+		switch {
+		case isMarkdown:
+			err = c.markdownRequest(ctx, systemPrompt, prompt, useModel, opts)
+		case isSubtitle:
+			err = c.subtitleRequest(ctx, systemPrompt, prompt, useModel, opts)
+		default:
+			err = c.multiRequest(ctx, systemPrompt, prompt, useModel, opts)
+		}
 		if err != nil {
-			fmt.Fprintf(c.errStream, "Error: %v\n", err)
-			return ExitCodeFail
+			return c.requestError(err)
 		}
 		return ExitCodeOK
 	}
@@ -290,6 +946,58 @@ If you find a problem, briefly explain the issue and provide a specific suggesti
 	return ExitCodeOK
 }
 
+// requestError prints err with a hint for API errors and returns the exit code for its category.
+func (c *CLI) requestError(err error) int {
+	fmt.Fprintf(c.errStream, "Error: %v\n", err)
+
+	var apiErr *apierror.APIError
+	if !errors.As(err, &apiErr) {
+		return ExitCodeFail
+	}
+
+	switch apiErr.Category() {
+	case apierror.CategoryAuth:
+		fmt.Fprintf(c.errStream, "Hint: Authentication failed. Check the API key for the %s backend.\n", apiErr.Provider)
+		return ExitCodeAuth
+	case apierror.CategoryRateLimit:
+		fmt.Fprintln(c.errStream, "Hint: The rate limit or quota was exceeded. Wait and try again, or increase -max-retries.")
+		return ExitCodeRateLimit
+	case apierror.CategoryContextLength:
+		fmt.Fprintln(c.errStream, "Hint: The input is too long for the model. Use -multi with a smaller -limit, or a model with a larger context window.")
+		return ExitCodeContextLength
+	case apierror.CategoryContentFilter:
+		fmt.Fprintln(c.errStream, "Hint: The request or the response was blocked by the content filter of the provider.")
+		return ExitCodeContentFilter
+	case apierror.CategoryNotFound:
+		fmt.Fprintln(c.errStream, "Hint: Check the model name given with -model.")
+	}
+	return ExitCodeFail
+}
+
+// printCommands lists the user-defined commands of the config files.
+func (c *CLI) printCommands(configs *configFiles) {
+	names := configs.commands()
+	if len(names) == 0 {
+		fmt.Fprintln(c.errStream, "No commands are defined. Declare them in [commands.<name>] tables of the config files.")
+		return
+	}
+	for _, name := range names {
+		cmd, _ := configs.command(name)
+		fmt.Fprintf(c.outStream, "%s\t%s\n", name, cmd.Description)
+	}
+}
+
+// isFlagSet reports whether the flag name was given on the command line.
+func isFlagSet(flags *flag.FlagSet, name string) bool {
+	set := false
+	flags.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
 func version() string {
 	if Version != "" {
 		return Version
@@ -301,122 +1009,315 @@ func version() string {
 	return info.Main.Version
 }
 
-// multiRequest processes multi-line input in chunks.
-func (c *CLI) multiRequest(ctx context.Context, systemPrompt, prompt, useModel string, limit int) error {
-	var b strings.Builder
-	reader := bufio.NewReader(c.inputStream)
-	for {
-		line, err := reader.ReadBytes('\n')
-		if err != nil {
-			if err == io.EOF {
-				break
-			}
-			return fmt.Errorf("error reading input: %w", err)
-		}
-		b.Write(line)
-		if b.Len() > limit {
-			translatedText, err := c.translator.request(ctx, systemPrompt, prompt, b.String(), useModel)
-			if err != nil {
-				return fmt.Errorf("failed to translate text: %w", err)
-			}
-			fmt.Fprintf(c.outStream, "%s\n", translatedText)
-			b.Reset()
-		}
-	}
-	if b.Len() > 0 {
-		translatedText, err := c.translator.request(ctx, systemPrompt, prompt, b.String(), useModel)
-		if err != nil {
-			return fmt.Errorf("failed to translate text: %w", err)
-		}
-		fmt.Fprintf(c.outStream, "%s\n", translatedText)
-		b.Reset()
-	}
-	return nil
-}
-
 // GeminiTranslator implements the Translator interface using the Gemini API client.
 type GeminiTranslator struct {
 	client *gemini.Client
+
+	usageMeter
 }
 
-// NewGeminiTranslator creates a new GeminiTranslator with the given API key.
-func NewGeminiTranslator(apiKey string) (*GeminiTranslator, error) {
+// NewGeminiTranslator creates a new GeminiTranslator with the given API key and retry policy.
+func NewGeminiTranslator(apiKey string, policy *retry.Policy) (*GeminiTranslator, error) {
 	client, err := gemini.NewClient(gemini.GeminiAPIURL, apiKey)
 	if err != nil {
 		return nil, fmt.Errorf("NewClient: %w", err)
 	}
+	client.Retry = policy
 	return &GeminiTranslator{client: client}, nil
 }
 
 // request sends a request to the Gemini API and returns the response text.
-// It constructs a Payload using the provided system prompt (if any) and user prompt.
+// The system prompt, if any, is sent as a system role message.
 func (gt *GeminiTranslator) request(ctx context.Context, systemPrompt, prompt, input, useModel string) (string, error) {
 	if len(input) == 0 {
 		return "", fmt.Errorf("no input")
 	}
-	var data *gemini.Payload
+	resp, err := gt.client.Chat(ctx, newChatCompletionsPayload(systemPrompt, prompt, input, useModel))
+	if err != nil {
+		return "", fmt.Errorf("http request: %w", err)
+	}
+	gt.add(resp.Usage.PromptTokens, resp.Usage.CompletionTokens)
+	return chatCompletionsText("gemini", resp)
+}
+
+// requestCandidates requests n responses to the Gemini API in one request.
+func (gt *GeminiTranslator) requestCandidates(ctx context.Context, systemPrompt, prompt, input, useModel string, n int) ([]string, error) {
+	if len(input) == 0 {
+		return nil, fmt.Errorf("no input")
+	}
+	payload := newChatCompletionsPayload(systemPrompt, prompt, input, useModel)
+	payload.N = n
+	resp, err := gt.client.Chat(ctx, payload)
+	if err != nil {
+		return nil, fmt.Errorf("http request: %w", err)
+	}
+	gt.add(resp.Usage.PromptTokens, resp.Usage.CompletionTokens)
+	return chatCompletionsCandidates(ctx, gt, "gemini", resp, systemPrompt, prompt, input, useModel, n)
+}
+
+// requestStream sends a streaming request to the Gemini API and writes the response text to w.
+func (gt *GeminiTranslator) requestStream(ctx context.Context, systemPrompt, prompt, input, useModel string, w io.Writer) error {
+	if len(input) == 0 {
+		return fmt.Errorf("no input")
+	}
+	usage, err := gt.client.ChatStream(ctx, newChatCompletionsPayload(systemPrompt, prompt, input, useModel), writeDelta(w))
+	if err != nil {
+		return fmt.Errorf("http request: %w", err)
+	}
+	if usage != nil {
+		gt.add(usage.PromptTokens, usage.CompletionTokens)
+	}
+	return nil
+}
+
+// OpenAICompatibleTranslator implements the Translator interface for servers
+// implementing the OpenAI chat/completions API, such as Ollama, llama.cpp server or vLLM.
+type OpenAICompatibleTranslator struct {
+	client *gemini.Client
+
+	usageMeter
+}
+
+// NewOpenAICompatibleTranslator creates a new OpenAICompatibleTranslator.
+// The apiKey may be empty.
+func NewOpenAICompatibleTranslator(baseURL, apiKey string, policy *retry.Policy) (*OpenAICompatibleTranslator, error) {
+	client, err := gemini.NewCompatibleClient(chatCompletionsURL(baseURL), apiKey)
+	if err != nil {
+		return nil, fmt.Errorf("NewCompatibleClient: %w", err)
+	}
+	client.Retry = policy
+	return &OpenAICompatibleTranslator{client: client}, nil
+}
+
+// chatCompletionsURL appends /chat/completions to baseURL unless it already points to the endpoint.
+func chatCompletionsURL(baseURL string) string {
+	baseURL = strings.TrimRight(baseURL, "/")
+	if strings.HasSuffix(baseURL, "/chat/completions") {
+		return baseURL
+	}
+	return baseURL + "/chat/completions"
+}
+
+// newChatCompletionsPayload constructs a Payload sending the system prompt, if any, as a system role message.
+func newChatCompletionsPayload(systemPrompt, prompt, input, useModel string) *gemini.Payload {
+	messages := make([]gemini.Message, 0, 2)
 	if systemPrompt != "" {
-		data = &gemini.Payload{
-			Model: useModel, // e.g., "gemini-2.0-flash"
-			Messages: []gemini.Message{
-				{Role: "user", Content: prompt + input},
-			},
+		messages = append(messages, gemini.Message{Role: "system", Content: systemPrompt})
+	}
+	messages = append(messages, gemini.Message{Role: "user", Content: prompt + input})
+	return &gemini.Payload{
+		Model:    useModel,
+		Messages: messages,
+	}
+}
+
+// request sends a request to the chat/completions endpoint and returns the response text.
+func (ct *OpenAICompatibleTranslator) request(ctx context.Context, systemPrompt, prompt, input, useModel string) (string, error) {
+	if len(input) == 0 {
+		return "", fmt.Errorf("no input")
+	}
+	resp, err := ct.client.Chat(ctx, newChatCompletionsPayload(systemPrompt, prompt, input, useModel))
+	if err != nil {
+		return "", fmt.Errorf("http request: %w", err)
+	}
+	ct.add(resp.Usage.PromptTokens, resp.Usage.CompletionTokens)
+	return chatCompletionsText("openai-compatible", resp)
+}
+
+// requestCandidates requests n responses to the chat/completions endpoint in one request.
+func (ct *OpenAICompatibleTranslator) requestCandidates(ctx context.Context, systemPrompt, prompt, input, useModel string, n int) ([]string, error) {
+	if len(input) == 0 {
+		return nil, fmt.Errorf("no input")
+	}
+	payload := newChatCompletionsPayload(systemPrompt, prompt, input, useModel)
+	payload.N = n
+	resp, err := ct.client.Chat(ctx, payload)
+	if err != nil {
+		return nil, fmt.Errorf("http request: %w", err)
+	}
+	ct.add(resp.Usage.PromptTokens, resp.Usage.CompletionTokens)
+	return chatCompletionsCandidates(ctx, ct, "openai-compatible", resp, systemPrompt, prompt, input, useModel, n)
+}
+
+// chatCompletionsText returns the content of the first choice of a chat/completions response.
+func chatCompletionsText(provider string, resp *gemini.Response) (string, error) {
+	if len(resp.Choices) == 0 {
+		return "", fmt.Errorf("no translation found")
+	}
+	if resp.Choices[0].FinishReason == "content_filter" {
+		return "", &apierror.APIError{Provider: provider, Code: "content_filter", Message: "the response was blocked by the content filter"}
+	}
+	return resp.Choices[0].Message.Content, nil
+}
+
+// chatCompletionsCandidates returns the contents of the choices of a chat/completions response.
+// Servers that ignore n return fewer choices, so the missing ones are requested one by one.
+func chatCompletionsCandidates(ctx context.Context, tr Translator, provider string, resp *gemini.Response, systemPrompt, prompt, input, useModel string, n int) ([]string, error) {
+	var texts []string
+	for _, choice := range resp.Choices {
+		if choice.FinishReason == "content_filter" {
+			continue
 		}
-	} else {
-		data = &gemini.Payload{
-			Model: useModel,
-			Messages: []gemini.Message{
-				{Role: "user", Content: prompt + input},
-			},
+		texts = append(texts, choice.Message.Content)
+	}
+	if len(texts) == 0 {
+		// The error of the first choice explains why there is no response.
+		if _, err := chatCompletionsText(provider, resp); err != nil {
+			return nil, err
+		}
+	}
+	if len(texts) < n {
+		more, err := requestEach(ctx, tr, systemPrompt, prompt, input, useModel, n-len(texts))
+		if err != nil {
+			return nil, err
 		}
+		texts = append(texts, more...)
 	}
-	resp, err := gt.client.Chat(ctx, data)
+	return texts, nil
+}
+
+// requestStream sends a streaming request to the chat/completions endpoint and writes the response text to w.
+func (ct *OpenAICompatibleTranslator) requestStream(ctx context.Context, systemPrompt, prompt, input, useModel string, w io.Writer) error {
+	if len(input) == 0 {
+		return fmt.Errorf("no input")
+	}
+	usage, err := ct.client.ChatStream(ctx, newChatCompletionsPayload(systemPrompt, prompt, input, useModel), writeDelta(w))
 	if err != nil {
-		return "", fmt.Errorf("http request: %w", err)
+		return fmt.Errorf("http request: %w", err)
 	}
-	if len(resp.Choices) > 0 {
-		return resp.Choices[0].Message.Content, nil
+	if usage != nil {
+		ct.add(usage.PromptTokens, usage.CompletionTokens)
 	}
-	return "", fmt.Errorf("no translation found")
+	return nil
 }
 
 // NewOpenAITranslator creates a new translator using the OpenAI API.
-func NewOpenAITranslator(apiKey string) (Translator, error) {
+func NewOpenAITranslator(apiKey string, policy *retry.Policy) (Translator, error) {
 	client, err := openai.NewClient(openai.OpenAIAPIURL, apiKey)
 	if err != nil {
 		return nil, fmt.Errorf("NewClient: %w", err)
 	}
+	client.Retry = policy
 	return &openaiTranslator{client: client}, nil
 }
 
 type openaiTranslator struct {
 	client *openai.Client
+
+	usageMeter
 }
 
-func (ot *openaiTranslator) request(ctx context.Context, systemPrompt, prompt, input, useModel string) (string, error) {
-	if len(input) == 0 {
-		return "", fmt.Errorf("no input")
-	}
-	var data *openai.Payload
+func newOpenAIPayload(systemPrompt, prompt, input, useModel string) *openai.Payload {
 	if systemPrompt != "" {
-		data = &openai.Payload{
+		return &openai.Payload{
 			Model:        useModel,
 			Input:        prompt + input,
 			Instructions: systemPrompt,
 		}
-	} else {
-		data = &openai.Payload{
-			Model: useModel,
-			Input: prompt + input,
-		}
 	}
-	resp, err := ot.client.Chat(ctx, data)
+	return &openai.Payload{
+		Model: useModel,
+		Input: prompt + input,
+	}
+}
+
+func (ot *openaiTranslator) request(ctx context.Context, systemPrompt, prompt, input, useModel string) (string, error) {
+	if len(input) == 0 {
+		return "", fmt.Errorf("no input")
+	}
+	resp, err := ot.client.Chat(ctx, newOpenAIPayload(systemPrompt, prompt, input, useModel))
 	if err != nil {
 		return "", fmt.Errorf("http request: %w", err)
 	}
+	ot.add(resp.Usage.InputTokens, resp.Usage.OutputTokens)
 	outputText := resp.OutputText()
 	if outputText != "" {
 		return outputText, nil
 	}
 	return "", fmt.Errorf("no translation found: Response=%+v", resp)
 }
+
+func (ot *openaiTranslator) requestStream(ctx context.Context, systemPrompt, prompt, input, useModel string, w io.Writer) error {
+	if len(input) == 0 {
+		return fmt.Errorf("no input")
+	}
+	usage, err := ot.client.ChatStream(ctx, newOpenAIPayload(systemPrompt, prompt, input, useModel), writeDelta(w))
+	if err != nil {
+		return fmt.Errorf("http request: %w", err)
+	}
+	if usage != nil {
+		ot.add(usage.InputTokens, usage.OutputTokens)
+	}
+	return nil
+}
+
+// AnthropicTranslator implements the Translator interface using the Anthropic Messages API client.
+type AnthropicTranslator struct {
+	client *anthropic.Client
+
+	usageMeter
+}
+
+// NewAnthropicTranslator creates a new AnthropicTranslator with the given API key and retry policy.
+func NewAnthropicTranslator(apiKey string, policy *retry.Policy) (*AnthropicTranslator, error) {
+	client, err := anthropic.NewClient(anthropic.AnthropicAPIURL, apiKey)
+	if err != nil {
+		return nil, fmt.Errorf("NewClient: %w", err)
+	}
+	client.Retry = policy
+	return &AnthropicTranslator{client: client}, nil
+}
+
+// newAnthropicPayload constructs a Payload sending the system prompt in the top-level system field.
+func newAnthropicPayload(systemPrompt, prompt, input, useModel string) *anthropic.Payload {
+	return &anthropic.Payload{
+		Model:  useModel,
+		System: systemPrompt,
+		Messages: []anthropic.Message{
+			{Role: "user", Content: prompt + input},
+		},
+	}
+}
+
+// request sends a request to the Anthropic API and returns the response text.
+func (at *AnthropicTranslator) request(ctx context.Context, systemPrompt, prompt, input, useModel string) (string, error) {
+	if len(input) == 0 {
+		return "", fmt.Errorf("no input")
+	}
+	resp, err := at.client.Chat(ctx, newAnthropicPayload(systemPrompt, prompt, input, useModel))
+	if err != nil {
+		return "", fmt.Errorf("http request: %w", err)
+	}
+	at.add(resp.Usage.InputTokens, resp.Usage.OutputTokens)
+	if resp.StopReason == "refusal" {
+		return "", &apierror.APIError{Provider: "anthropic", Code: "refusal", Message: "the model refused to respond"}
+	}
+	outputText := resp.OutputText()
+	if outputText != "" {
+		return outputText, nil
+	}
+	return "", fmt.Errorf("no translation found: Response=%+v", resp)
+}
+
+// requestStream sends a streaming request to the Anthropic API and writes the response text to w.
+func (at *AnthropicTranslator) requestStream(ctx context.Context, systemPrompt, prompt, input, useModel string, w io.Writer) error {
+	if len(input) == 0 {
+		return fmt.Errorf("no input")
+	}
+	usage, err := at.client.ChatStream(ctx, newAnthropicPayload(systemPrompt, prompt, input, useModel), writeDelta(w))
+	if err != nil {
+		return fmt.Errorf("http request: %w", err)
+	}
+	if usage != nil {
+		at.add(usage.InputTokens, usage.OutputTokens)
+	}
+	return nil
+}
+
+// writeDelta returns a callback that writes each streamed delta to w.
+func writeDelta(w io.Writer) func(delta string) error {
+	return func(delta string) error {
+		_, err := io.WriteString(w, delta)
+		return err
+	}
+}
@@ -3,8 +3,12 @@ package cli_test
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -90,3 +94,129 @@ func TestCLI_translateFile(t *testing.T) {
 		t.Errorf("expected %q, but got %q", expected, outStream.String())
 	}
 }
+
+func TestRun_unknownBackend(t *testing.T) {
+	_, errOut, status := runBento(t, nil, env{}, "-backend", "unknown", "-commit")
+	if status != ExitCodeFail {
+		t.Errorf("ExitStatus=%d, want %d", status, ExitCodeFail)
+	}
+
+	expected := `Unknown backend "unknown"`
+	if !strings.Contains(errOut, expected) {
+		t.Errorf("Output=%q, want %q", errOut, expected)
+	}
+}
+
+func TestRun_openAICompatibleBackend(t *testing.T) {
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	mux.HandleFunc("/v1/chat/completions", func(w http.ResponseWriter, r *http.Request) {
+		payload := struct {
+			Model    string `json:"model"`
+			Messages []struct {
+				Role    string `json:"role"`
+				Content string `json:"content"`
+			} `json:"messages"`
+		}{}
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Fatal(err)
+		}
+		if payload.Model != "llama3" {
+			t.Errorf("model expected llama3, got %s", payload.Model)
+		}
+		if len(payload.Messages) != 2 || payload.Messages[0].Role != "system" || payload.Messages[0].Content != "Be brief." {
+			t.Errorf("unexpected messages: %+v", payload.Messages)
+		}
+		fmt.Fprint(w, `{"choices":[{"index":0,"message":{"role":"assistant","content":"fix-typo"}}]}`)
+	})
+
+	t.Setenv("BENTO_BASE_URL", server.URL+"/v1")
+	t.Setenv("BENTO_API_KEY", "")
+
+	out, _ := mustRunBento(t, nil, env{terminal: true}, "-backend", "openai-compatible", "-model", "llama3", "-system", "Be brief.", "-branch", "-file", "testdata/test.txt")
+	if out != "fix-typo\n" {
+		t.Errorf("Output=%q, want %q", out, "fix-typo\n")
+	}
+}
+
+func TestRun_stream(t *testing.T) {
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	mux.HandleFunc("/v1/chat/completions", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, delta := range []string{"fix", "-", "typo"} {
+			fmt.Fprintf(w, "data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":%q}}]}\n\n", delta)
+			w.(http.Flusher).Flush()
+		}
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	})
+
+	t.Setenv("BENTO_BASE_URL", server.URL+"/v1")
+
+	out, _ := mustRunBento(t, nil, env{terminal: true}, "-backend", "openai-compatible", "-model", "llama3", "-stream", "-branch", "-file", "testdata/test.txt")
+	if out != "fix-typo\n" {
+		t.Errorf("Output=%q, want %q", out, "fix-typo\n")
+	}
+}
+
+func TestRun_streamIncomplete(t *testing.T) {
+	// The connection is dropped before [DONE].
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":\"fix\"}}]}\n\n")
+	}))
+	defer server.Close()
+
+	cacheDir := t.TempDir()
+	t.Setenv("BENTO_BASE_URL", server.URL+"/v1")
+	t.Setenv("BENTO_CACHE_DIR", cacheDir)
+
+	_, errOut, status := runBento(t, nil, env{terminal: true}, "-backend", "openai-compatible", "-model", "llama3", "-stream", "-cache", "-branch", "-file", "testdata/test.txt")
+	if status != ExitCodeFail {
+		t.Fatalf("ExitStatus=%d, want %d; stderr=%q", status, ExitCodeFail, errOut)
+	}
+	if !strings.Contains(errOut, "stream ended before completion") {
+		t.Errorf("unexpected error %q", errOut)
+	}
+	// The truncated response is not cached.
+	if entries, _ := os.ReadDir(filepath.Join(cacheDir, "responses")); len(entries) != 0 {
+		t.Errorf("expected nothing to be cached, got %v", entries)
+	}
+}
+
+func TestRun_apiErrorExitCode(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		body     string
+		exitCode int
+	}{
+		{"auth", http.StatusUnauthorized, `{"error":{"message":"Incorrect API key provided","type":"invalid_request_error","code":"invalid_api_key"}}`, ExitCodeAuth},
+		{"context length", http.StatusBadRequest, `{"error":{"message":"This model's maximum context length is 8192 tokens","type":"invalid_request_error","code":"context_length_exceeded"}}`, ExitCodeContextLength},
+		{"other", http.StatusBadRequest, `{"error":{"message":"Invalid value","type":"invalid_request_error"}}`, ExitCodeFail},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				fmt.Fprint(w, tt.body)
+			}))
+			defer server.Close()
+
+			t.Setenv("BENTO_BASE_URL", server.URL)
+
+			_, errOut, status := runBento(t, nil, env{terminal: true}, "-backend", "openai-compatible", "-model", "llama3", "-commit", "-file", "testdata/test.txt")
+			if status != tt.exitCode {
+				t.Errorf("ExitStatus=%d, want %d; stderr=%q", status, tt.exitCode, errOut)
+			}
+			if tt.exitCode == ExitCodeAuth && !strings.Contains(errOut, "for the openai-compatible backend") {
+				t.Errorf("expected the hint to name the openai-compatible backend, got %q", errOut)
+			}
+		})
+	}
+}
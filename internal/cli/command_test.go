@@ -0,0 +1,82 @@
+package cli_test
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/catatsuy/bento/internal/cli"
+)
+
+func TestRun_userDefinedCommand(t *testing.T) {
+	userConfig := `
+model = "user-model"
+
+[commands.summarize-incident]
+description = "Summarize an incident"
+prompt = "Summarize the following incident:\n\n"
+system = "You are an SRE."
+model = "incident-model"
+mode = "multi"
+limit = 25
+`
+	repoConfig := `
+[commands.write-changelog]
+description = "Write a changelog entry"
+prompt = "Write a changelog entry:\n\n"
+`
+	input := setupConfig(t, userConfig, repoConfig)
+
+	m := newMockModel("ok")
+	tr := m.translator()
+	mustRunBento(t, tr, env{terminal: true}, "run", "summarize-incident", "-file", input)
+
+	// The multi mode with a limit of 25 sends each line of testdata/test.txt separately.
+	calls := m.calls()
+	if len(calls) != 2 {
+		t.Fatalf("expected 2 requests, got %d", len(calls))
+	}
+	expected := request{"You are an SRE.", "Summarize the following incident:\n\n", "This is a test.\n", "incident-model"}
+	if calls[0] != expected {
+		t.Errorf("got %+v, want %+v", calls[0], expected)
+	}
+
+	// Flags override the values of the command.
+	mustRunBento(t, tr, env{terminal: true}, "run", "summarize-incident", "-single", "-model", "flag-model", "-file", input)
+	if calls := m.calls()[2:]; len(calls) != 1 || calls[0].model != "flag-model" {
+		t.Errorf("expected a single request with flag-model, got %+v", calls)
+	}
+
+	// Commands from the repository config are available too.
+	mustRunBento(t, tr, env{terminal: true}, "run", "write-changelog", "-file", input)
+	if calls := m.calls()[3:]; len(calls) != 1 || calls[0].prompt != "Write a changelog entry:\n\n" || calls[0].model != "user-model" {
+		t.Errorf("unexpected requests: %+v", calls)
+	}
+}
+
+func TestRun_listCommands(t *testing.T) {
+	setupConfig(t, `
+[commands.b]
+description = "Command B"
+
+[commands.a]
+description = "Command A"
+`, "")
+
+	out, _ := mustRunBento(t, &MockTranslator{}, env{terminal: true}, "run")
+	expected := "a\tCommand A\nb\tCommand B\n"
+	if out != expected {
+		t.Errorf("Output=%q, want %q", out, expected)
+	}
+}
+
+func TestRun_unknownCommand(t *testing.T) {
+	input := setupConfig(t, "", "")
+
+	_, errOut, status := runBento(t, &MockTranslator{}, env{terminal: true}, "run", "missing", "-file", input)
+	if status != ExitCodeFail {
+		t.Errorf("ExitStatus=%d, want %d", status, ExitCodeFail)
+	}
+	if !strings.Contains(errOut, `Unknown command "missing"`) {
+		t.Errorf("Output=%q", errOut)
+	}
+}
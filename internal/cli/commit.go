@@ -0,0 +1,57 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/catatsuy/bento/internal/commitmsg"
+)
+
+// commitPrompts are the prompts of -commit for the commit styles. The custom style uses the prompt of the user.
+var commitPrompts = map[commitmsg.Style]string{
+	commitmsg.Subject: "Generate a commit message directly from the provided source code differences without any additional text or formatting within 72 characters:\n\n",
+	commitmsg.Plain: `Generate a commit message from the provided source code differences.
+Write a subject line of at most 72 characters in the imperative mood, then a blank line, then a body explaining what changed and why, wrapped at 72 characters. Leave out the body for trivial changes.
+Output only the commit message without any additional text or formatting:
+
+`,
+	commitmsg.Conventional: `Generate a commit message following the Conventional Commits specification from the provided source code differences.
+The subject line must be "type(scope): description" within 72 characters, where type is a lowercase type such as feat, fix, docs, style, refactor, perf, test, build, ci or chore, the scope in parentheses is optional, and "!" before the colon marks a breaking change. Write the description in the imperative mood.
+Then write a blank line and a body explaining what changed and why, wrapped at 72 characters. Leave out the body for trivial changes. Add a "BREAKING CHANGE:" footer for breaking changes.
+Output only the commit message without any additional text or formatting:
+
+`,
+	commitmsg.Gitmoji: `Generate a commit message following the gitmoji convention from the provided source code differences.
+The subject line must start with the gitmoji that matches the change, such as ✨ for a new feature, 🐛 for a bug fix, 📝 for documentation, ♻️ for refactoring, ✅ for tests or 🔧 for configuration, followed by a space and a description in the imperative mood, within 72 characters.
+Then write a blank line and a body explaining what changed and why, wrapped at 72 characters. Leave out the body for trivial changes.
+Output only the commit message without any additional text or formatting:
+
+`,
+}
+
+// checkCommitMessage formats a suggested commit message and validates it against style.
+// An invalid message is requested once more with the validation error in the prompt.
+// If the new message is still invalid, it is returned with a warning.
+func (c *CLI) checkCommitMessage(ctx context.Context, style commitmsg.Style, systemPrompt, prompt, input, model, suggestion string) (string, error) {
+	msg := commitmsg.Format(commitMessageOf(suggestion))
+	verr := commitmsg.Validate(msg, style)
+	if verr == nil {
+		return msg, nil
+	}
+
+	suggestion, err := c.translator.request(ctx, systemPrompt, retryCommitPrompt(prompt, msg, verr), input, model)
+	if err != nil {
+		return "", err
+	}
+	msg = commitmsg.Format(commitMessageOf(suggestion))
+	if verr := commitmsg.Validate(msg, style); verr != nil {
+		fmt.Fprintf(c.errStream, "Warning: The suggested commit message is invalid: %v.\n", verr)
+	}
+	return msg, nil
+}
+
+// retryCommitPrompt returns the prompt asking again for a commit message that broke a rule.
+func retryCommitPrompt(prompt, msg string, err error) string {
+	retry := fmt.Sprintf("The following commit message was rejected because %v:\n\n%s\n\nWrite the commit message again following the rules.\n\n", err, msg)
+	return escapeTemplate(retry) + prompt
+}
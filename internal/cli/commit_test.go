@@ -0,0 +1,113 @@
+package cli_test
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/catatsuy/bento/internal/cli"
+)
+
+// runCommit runs bento -commit with the suggestions returned in order and returns
+// the output, the standard error and the prompts of the requests.
+func runCommit(t *testing.T, input string, suggestions []string, args ...string) (string, string, []string) {
+	t.Helper()
+
+	m := newMockModel(suggestions...)
+	out, errOut := mustRunBento(t, m.translator(), env{terminal: true}, append([]string{"-commit", "-file", input}, args...)...)
+	var prompts []string
+	for _, r := range m.calls() {
+		prompts = append(prompts, r.prompt)
+	}
+	return out, errOut, prompts
+}
+
+func TestRun_commitStyle(t *testing.T) {
+	input := setupConfig(t, "", "")
+
+	out, errOut, prompts := runCommit(t, input, []string{"feat(cli): add -n\n\nThe picker lets the user choose one of several suggestions with the arrow keys."}, "-commit-style", "conventional")
+	want := "feat(cli): add -n\n\nThe picker lets the user choose one of several suggestions with the\narrow keys.\n"
+	if out != want {
+		t.Errorf("output=%q, want %q", out, want)
+	}
+	if errOut != "" {
+		t.Errorf("expected no warning, got %q", errOut)
+	}
+	if len(prompts) != 1 || !strings.Contains(prompts[0], "Conventional Commits") {
+		t.Errorf("expected one request with the Conventional Commits prompt, got %q", prompts)
+	}
+}
+
+func TestRun_commitStyleRetry(t *testing.T) {
+	input := setupConfig(t, "", "")
+
+	out, errOut, prompts := runCommit(t, input, []string{"Add -n", "feat(cli): add -n"}, "-commit-style", "conventional")
+	if out != "feat(cli): add -n\n" {
+		t.Errorf("output=%q, want the second suggestion", out)
+	}
+	if errOut != "" {
+		t.Errorf("expected no warning, got %q", errOut)
+	}
+	if len(prompts) != 2 {
+		t.Fatalf("expected 2 requests, got %d", len(prompts))
+	}
+	if !strings.Contains(prompts[1], "rejected because") || !strings.Contains(prompts[1], "Add -n") {
+		t.Errorf("expected the second prompt to explain why the message was rejected, got %q", prompts[1])
+	}
+
+	// The model is asked again only once.
+	out, errOut, prompts = runCommit(t, input, []string{"Add -n"}, "-commit-style", "gitmoji")
+	if out != "Add -n\n" || len(prompts) != 2 {
+		t.Errorf("expected the invalid message after 2 requests, got %q after %d", out, len(prompts))
+	}
+	if !strings.Contains(errOut, "Warning: The suggested commit message is invalid") {
+		t.Errorf("expected a warning, got %q", errOut)
+	}
+
+	// Without a style, the message must be a single line.
+	out, _, prompts = runCommit(t, input, []string{"Add -n\n\nThe body.", "Add -n"})
+	if out != "Add -n\n" || len(prompts) != 2 {
+		t.Errorf("expected a single line after 2 requests, got %q after %d", out, len(prompts))
+	}
+
+	// Braces in the rejected message are not template actions.
+	_, _, prompts = runCommit(t, input, []string{"Fix {{.Input}}", "fix: escape braces"}, "-commit-style", "conventional")
+	if len(prompts) != 2 || !strings.Contains(prompts[1], "Fix {{.Input}}") {
+		t.Errorf("expected the rejected message in the prompt as is, got %q", prompts)
+	}
+}
+
+func TestRun_commitStyleCustom(t *testing.T) {
+	input := setupConfig(t, "", "")
+
+	_, _, prompts := runCommit(t, input, []string{"Fix typo"}, "-commit-style", "custom", "-prompt", "Write a commit message in Japanese:\n\n")
+	if len(prompts) != 1 || prompts[0] != "Write a commit message in Japanese:\n\n" {
+		t.Errorf("expected the custom prompt, got %q", prompts)
+	}
+}
+
+func TestRun_commitStyleConfig(t *testing.T) {
+	input := setupConfig(t, "", `commit_style = "gitmoji"`)
+
+	_, _, prompts := runCommit(t, input, []string{"✨ Add -n"})
+	if len(prompts) != 1 || !strings.Contains(prompts[0], "gitmoji") {
+		t.Errorf("expected the gitmoji prompt, got %q", prompts)
+	}
+
+	// The commit style of the config file does not make other modes fail.
+	runWithMock(t, "-branch", "-file", input)
+}
+
+func TestRun_commitStyleErrors(t *testing.T) {
+	input := setupConfig(t, "", "")
+
+	tests := [][]string{
+		{"-commit", "-commit-style", "angular", "-file", input},
+		{"-branch", "-commit-style", "plain", "-file", input},
+		{"-commit", "-commit-style", "custom", "-file", input},
+	}
+	for _, args := range tests {
+		if _, _, status := runBento(t, &MockTranslator{}, env{terminal: true}, args...); status != ExitCodeFail {
+			t.Errorf("%v: ExitStatus=%d, want %d", args, status, ExitCodeFail)
+		}
+	}
+}
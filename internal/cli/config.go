@@ -0,0 +1,333 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// RepoConfigFileName is the name of the repository-local config file.
+const RepoConfigFileName = ".bento.toml"
+
+// Settings are the default values of the flags that can be set in config files and environment variables.
+// Zero values mean unset.
+type Settings struct {
+	Backend  string `toml:"backend"`
+	Model    string `toml:"model"`
+	Language string `toml:"language"`
+	System   string `toml:"system"`
+	BaseURL  string `toml:"base_url"`
+	Limit    int    `toml:"limit"`
+	// CacheDir is the directory of the translation memory.
+	CacheDir string `toml:"cache_dir"`
+	// CommitStyle is the style of the commit messages suggested with -commit.
+	CommitStyle string `toml:"commit_style"`
+}
+
+// merge overrides s with the values set in o.
+func (s *Settings) merge(o Settings) {
+	if o.Backend != "" {
+		s.Backend = o.Backend
+	}
+	if o.Model != "" {
+		s.Model = o.Model
+	}
+	if o.Language != "" {
+		s.Language = o.Language
+	}
+	if o.System != "" {
+		s.System = o.System
+	}
+	if o.BaseURL != "" {
+		s.BaseURL = o.BaseURL
+	}
+	if o.Limit != 0 {
+		s.Limit = o.Limit
+	}
+	if o.CacheDir != "" {
+		s.CacheDir = o.CacheDir
+	}
+	if o.CommitStyle != "" {
+		s.CommitStyle = o.CommitStyle
+	}
+}
+
+// untrustedKeys are the settings a repository config cannot set. A cloned repository could
+// otherwise send the diffs and the API key to its own server or make bento write and prune
+// files in any directory.
+var untrustedKeys = []string{"backend", "base_url", "cache_dir"}
+
+// dropUntrusted clears the settings of untrustedKeys and returns the keys that were set.
+func (s *Settings) dropUntrusted() []string {
+	var keys []string
+	for i, v := range []*string{&s.Backend, &s.BaseURL, &s.CacheDir} {
+		if *v != "" {
+			keys = append(keys, untrustedKeys[i])
+			*v = ""
+		}
+	}
+	return keys
+}
+
+// Config is the content of a config file.
+//
+//	model = "gpt-5-mini"
+//
+//	[profiles.local]
+//	backend = "openai-compatible"
+//	base_url = "http://localhost:11434/v1"
+//	model = "llama3"
+//
+//	[commands.write-changelog]
+//	description = "Write a changelog entry from a diff"
+//	prompt = "Write a changelog entry for the following changes:\n\n"
+type Config struct {
+	Settings
+
+	// Profile is the profile used when -profile is not given.
+	Profile  string              `toml:"profile"`
+	Profiles map[string]Settings `toml:"profiles"`
+
+	// Commands are the user-defined commands invoked with "bento run <name>".
+	Commands map[string]Command `toml:"commands"`
+}
+
+// Command is a user-defined command declared in a config file.
+// Its values are used as flag defaults, so flags given on the command line override them.
+type Command struct {
+	Description string `toml:"description"`
+	Prompt      string `toml:"prompt"`
+	System      string `toml:"system"`
+	// Mode is "single" (default) or "multi".
+	Mode  string `toml:"mode"`
+	Model string `toml:"model"`
+	Limit int    `toml:"limit"`
+}
+
+// command returns the command with the given name. Commands in the repository config
+// take precedence over commands with the same name in the user config.
+func (f *configFiles) command(name string) (Command, bool) {
+	if cmd, ok := f.repo.Commands[name]; ok {
+		return cmd, true
+	}
+	cmd, ok := f.user.Commands[name]
+	return cmd, ok
+}
+
+// commands returns all the commands sorted by name.
+func (f *configFiles) commands() []string {
+	names := make([]string, 0, len(f.user.Commands)+len(f.repo.Commands))
+	for name := range f.user.Commands {
+		names = append(names, name)
+	}
+	for name := range f.repo.Commands {
+		if _, ok := f.user.Commands[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
+// loadConfig reads the config file at path. A missing file is not an error.
+func loadConfig(path string) (*Config, error) {
+	conf := &Config{}
+	_, err := toml.DecodeFile(path, conf)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return conf, nil
+		}
+		return nil, fmt.Errorf("failed to load %s: %w", path, err)
+	}
+	return conf, nil
+}
+
+// userConfigPath returns the path of the user config file.
+// BENTO_CONFIG overrides the default $XDG_CONFIG_HOME/bento/config.toml.
+func userConfigPath() string {
+	if p := os.Getenv("BENTO_CONFIG"); p != "" {
+		return p
+	}
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "bento", "config.toml")
+}
+
+// repoConfigPath looks for .bento.toml from dir up to the root of the Git repository.
+// It returns an empty string if there is none.
+func repoConfigPath(dir string) string {
+	for {
+		p := filepath.Join(dir, RepoConfigFileName)
+		if _, err := os.Stat(p); err == nil {
+			return p
+		}
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return ""
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// configFiles holds the loaded user and repository config files.
+type configFiles struct {
+	user, repo *Config
+
+	// repoPath is the path of the repository config file, if any.
+	repoPath string
+	// ignored are the keys of untrustedKeys set in the repository config, which are ignored.
+	ignored []string
+}
+
+// loadConfigFiles loads the user config file and the repository config file found from the current directory.
+func loadConfigFiles() (*configFiles, error) {
+	files := &configFiles{user: &Config{}, repo: &Config{}}
+
+	var err error
+	if p := userConfigPath(); p != "" {
+		files.user, err = loadConfig(p)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+	if p := repoConfigPath(wd); p != "" {
+		files.repo, err = loadConfig(p)
+		if err != nil {
+			return nil, err
+		}
+		files.repoPath = p
+		files.ignored = files.repo.Settings.dropUntrusted()
+		for name, profile := range files.repo.Profiles {
+			files.ignored = append(files.ignored, profile.dropUntrusted()...)
+			files.repo.Profiles[name] = profile
+		}
+		slices.Sort(files.ignored)
+		files.ignored = slices.Compact(files.ignored)
+	}
+
+	return files, nil
+}
+
+// settings resolves the settings of profile with the precedence
+// env > profile > top-level settings, where the repository config
+// takes precedence over the user config at each level.
+// If profile is empty, BENTO_PROFILE or the profile named in the config files, if any, is used.
+func (f *configFiles) settings(profile string) (Settings, error) {
+	if profile == "" {
+		profile = os.Getenv("BENTO_PROFILE")
+	}
+	if profile == "" {
+		profile = f.repo.Profile
+	}
+	if profile == "" {
+		profile = f.user.Profile
+	}
+
+	var s Settings
+	s.merge(f.user.Settings)
+	s.merge(f.repo.Settings)
+
+	if profile != "" {
+		found := false
+		for _, conf := range []*Config{f.user, f.repo} {
+			if p, ok := conf.Profiles[profile]; ok {
+				s.merge(p)
+				found = true
+			}
+		}
+		if !found {
+			return Settings{}, fmt.Errorf("profile %q is not defined in the config files", profile)
+		}
+	}
+
+	env, err := envSettings()
+	if err != nil {
+		return Settings{}, err
+	}
+	s.merge(env)
+
+	return s, nil
+}
+
+// envSettings returns the settings given by the BENTO_* environment variables.
+func envSettings() (Settings, error) {
+	s := Settings{
+		Backend:  os.Getenv("BENTO_BACKEND"),
+		Model:    os.Getenv("BENTO_MODEL"),
+		Language: os.Getenv("BENTO_LANGUAGE"),
+		System:   os.Getenv("BENTO_SYSTEM"),
+		BaseURL:  os.Getenv("BENTO_BASE_URL"),
+		CacheDir: os.Getenv("BENTO_CACHE_DIR"),
+
+		CommitStyle: os.Getenv("BENTO_COMMIT_STYLE"),
+	}
+	if v := os.Getenv("BENTO_LIMIT"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil {
+			return Settings{}, fmt.Errorf("invalid BENTO_LIMIT %q: %w", v, err)
+		}
+		s.Limit = limit
+	}
+	return s, nil
+}
+
+// lookupFlag returns the value of the flag name in args without parsing the other flags.
+// It is used to find flags that are needed before the flags are defined.
+func lookupFlag(args []string, name string) string {
+	for i, arg := range args {
+		if arg == "--" {
+			break
+		}
+		for _, prefix := range []string{"-", "--"} {
+			if arg == prefix+name && i+1 < len(args) {
+				return args[i+1]
+			}
+			if v, ok := strings.CutPrefix(arg, prefix+name+"="); ok {
+				return v
+			}
+		}
+	}
+	return ""
+}
+
+// hasFlag reports whether args set one of the boolean flags names, before the flags are parsed.
+func hasFlag(args []string, names ...string) bool {
+	for _, arg := range args {
+		if arg == "--" {
+			break
+		}
+		name, ok := strings.CutPrefix(arg, "-")
+		if !ok {
+			continue
+		}
+		name, value, hasValue := strings.Cut(strings.TrimPrefix(name, "-"), "=")
+		if !slices.Contains(names, name) {
+			continue
+		}
+		if v, err := strconv.ParseBool(value); !hasValue || (err == nil && v) {
+			return true
+		}
+	}
+	return false
+}
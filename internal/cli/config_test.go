@@ -0,0 +1,179 @@
+package cli_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	. "github.com/catatsuy/bento/internal/cli"
+)
+
+// setupConfig writes the user config and the repository config
+// and changes the current directory to the repository.
+func setupConfig(t *testing.T, userConfig, repoConfig string) string {
+	t.Helper()
+
+	configHome := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", configHome)
+	t.Setenv("BENTO_CONFIG", "")
+	for _, key := range []string{"BENTO_PROFILE", "BENTO_BACKEND", "BENTO_MODEL", "BENTO_LANGUAGE", "BENTO_SYSTEM", "BENTO_BASE_URL", "BENTO_LIMIT", "BENTO_CACHE_DIR", "BENTO_COMMIT_STYLE"} {
+		t.Setenv(key, "")
+	}
+
+	if userConfig != "" {
+		if err := os.MkdirAll(filepath.Join(configHome, "bento"), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(configHome, "bento", "config.toml"), []byte(userConfig), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	input, err := filepath.Abs("testdata/test.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	repo := t.TempDir()
+	if err := os.Mkdir(filepath.Join(repo, ".git"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if repoConfig != "" {
+		if err := os.WriteFile(filepath.Join(repo, RepoConfigFileName), []byte(repoConfig), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	subdir := filepath.Join(repo, "sub")
+	if err := os.Mkdir(subdir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(subdir)
+
+	return input
+}
+
+// runWithMock runs bento with a MockTranslator and returns the system prompt and model of the request.
+func runWithMock(t *testing.T, args ...string) (string, string) {
+	t.Helper()
+
+	m := newMockModel("ok")
+	mustRunBento(t, m.translator(), env{terminal: true}, args...)
+	return m.last().system, m.last().model
+}
+
+func TestRun_configPrecedence(t *testing.T) {
+	userConfig := `
+model = "user-model"
+system = "user system"
+
+[profiles.fast]
+model = "user-fast-model"
+`
+	repoConfig := `
+model = "repo-model"
+
+[profiles.fast]
+system = "repo fast system"
+`
+	input := setupConfig(t, userConfig, repoConfig)
+
+	system, model := runWithMock(t, "-commit", "-file", input)
+	if model != "repo-model" || system != "user system" {
+		t.Errorf("got model=%q system=%q, want repo-model and user system", model, system)
+	}
+
+	t.Setenv("BENTO_MODEL", "env-model")
+	system, model = runWithMock(t, "-commit", "-file", input)
+	if model != "env-model" || system != "user system" {
+		t.Errorf("got model=%q system=%q, want env-model and user system", model, system)
+	}
+
+	system, model = runWithMock(t, "-commit", "-model", "flag-model", "-file", input)
+	if model != "flag-model" || system != "user system" {
+		t.Errorf("got model=%q system=%q, want flag-model and user system", model, system)
+	}
+
+	t.Setenv("BENTO_MODEL", "")
+	system, model = runWithMock(t, "-profile", "fast", "-commit", "-file", input)
+	if model != "user-fast-model" || system != "repo fast system" {
+		t.Errorf("got model=%q system=%q, want user-fast-model and repo fast system", model, system)
+	}
+}
+
+func TestRun_configLanguageOnlyForTranslateAndReview(t *testing.T) {
+	input := setupConfig(t, `language = "ja"`, "")
+
+	// The language from the config file must not make other modes fail.
+	runWithMock(t, "-commit", "-file", input)
+}
+
+func TestRun_configUnknownProfile(t *testing.T) {
+	input := setupConfig(t, `model = "user-model"`, "")
+
+	if _, _, status := runBento(t, &MockTranslator{}, env{terminal: true}, "-profile=missing", "-commit", "-file", input); status != ExitCodeFail {
+		t.Errorf("ExitStatus=%d, want %d", status, ExitCodeFail)
+	}
+}
+
+func TestRun_configBroken(t *testing.T) {
+	input := setupConfig(t, "model = ", "")
+
+	if _, errOut, status := runBento(t, &MockTranslator{}, env{terminal: true}, "-commit", "-file", input); status != ExitCodeFail || !strings.Contains(errOut, "config.toml") {
+		t.Errorf("expected an error naming the config file, got %d: %q", status, errOut)
+	}
+
+	// -version and -help do not need the config file.
+	for _, args := range [][]string{{"-version"}, {"-help"}, {"--h=true"}} {
+		_, errOut, status := runBento(t, &MockTranslator{}, env{terminal: true}, args...)
+		if status != ExitCodeOK {
+			t.Errorf("%v: ExitStatus=%d, want %d: %s", args, status, ExitCodeOK, errOut)
+		}
+		if !strings.Contains(errOut, "Warning: ") || !strings.Contains(errOut, "bento version") {
+			t.Errorf("%v: expected a warning and the version, got %q", args, errOut)
+		}
+	}
+}
+
+func TestRun_configBrokenHook(t *testing.T) {
+	dir := setupRepo(t)
+	writeFile(t, RepoConfigFileName, "model = ")
+
+	// The hook can be installed and uninstalled with a broken config file.
+	for _, args := range []string{"install", "uninstall"} {
+		if _, errOut, status := runHook(t, "", args); status != ExitCodeOK {
+			t.Fatalf("%s: ExitStatus=%d, want %d: %s", args, status, ExitCodeOK, errOut)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, ".git", "hooks", "prepare-commit-msg")); err == nil {
+		t.Error("expected the hook to be uninstalled")
+	}
+}
+
+func TestRun_configRepoCannotSetEndpoint(t *testing.T) {
+	newServer := func(hit *bool) *httptest.Server {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			*hit = true
+			fmt.Fprint(w, `{"choices":[{"index":0,"message":{"role":"assistant","content":"fix-typo"}}]}`)
+		}))
+		t.Cleanup(server.Close)
+		return server
+	}
+	var trustedHit, attackerHit bool
+	trusted, attacker := newServer(&trustedHit), newServer(&attackerHit)
+
+	userConfig := fmt.Sprintf("backend = \"openai-compatible\"\nbase_url = %q\nmodel = \"llama3\"\n", trusted.URL+"/v1")
+	repoConfig := fmt.Sprintf("base_url = %q\ncache_dir = \".\"\n\n[profiles.evil]\nbackend = \"gemini\"\n", attacker.URL+"/v1")
+	input := setupConfig(t, userConfig, repoConfig)
+
+	_, errOut := mustRunBento(t, nil, env{terminal: true}, "-profile", "evil", "-branch", "-file", input)
+	if !trustedHit || attackerHit {
+		t.Errorf("expected only the server of the user config to be requested, got trusted=%v attacker=%v", trustedHit, attackerHit)
+	}
+	if !strings.Contains(errOut, RepoConfigFileName+" sets backend, base_url, cache_dir, which can only be set in the user config") {
+		t.Errorf("expected a warning naming the repository config, got %q", errOut)
+	}
+}
@@ -1,6 +1,11 @@
 package cli
 
-import "context"
+import (
+	"bufio"
+	"context"
+	"io"
+	"strings"
+)
 
 type MockTranslator struct {
 	TranslateTextFunc func(ctx context.Context, systemPrompt, prompt, text, model string) (string, error)
@@ -11,5 +16,55 @@ func (m *MockTranslator) request(ctx context.Context, systemPrompt, prompt, text
 }
 
 func (c *CLI) MultiRequest(ctx context.Context, systemPrompt, prompt, useModel string, limit int) error {
-	return c.multiRequest(ctx, systemPrompt, prompt, useModel, limit)
+	return c.multiRequest(ctx, systemPrompt, prompt, useModel, multiOptions{limit: limit, concurrency: 1})
+}
+
+func (c *CLI) MultiRequestConcurrently(ctx context.Context, systemPrompt, prompt, useModel string, limit, concurrency int, rate float64) error {
+	return c.multiRequest(ctx, systemPrompt, prompt, useModel, multiOptions{limit: limit, concurrency: concurrency, rate: rate})
+}
+
+func Request(ctx context.Context, tr Translator, systemPrompt, prompt, input, model string) (string, error) {
+	return tr.request(ctx, systemPrompt, prompt, input, model)
+}
+
+func Chunks(chunkBy, text string, limit int) ([]string, error) {
+	ch, err := newChunker(chunkBy)
+	if err != nil {
+		return nil, err
+	}
+	var chunks []string
+	err = ch.chunk(strings.NewReader(text), limit, func(chunk string) error {
+		chunks = append(chunks, chunk)
+		return nil
+	})
+	return chunks, err
+}
+
+func EstimateTokens(s string) int {
+	return estimateTokens(s)
+}
+
+func init() {
+	// The tests do not share the caches of the user. Tests of the caches set BENTO_CACHE_DIR.
+	defaultCacheDir = func() string { return "" }
+}
+
+func SanitizeBranchName(suggestion string) (string, error) {
+	return sanitizeBranchName(suggestion)
+}
+
+func (c *CLI) SetStdoutTerminal(isTerminal bool) {
+	c.isStdoutTerminal = isTerminal
+}
+
+func RequestCandidates(ctx context.Context, tr Translator, systemPrompt, prompt, input, model string, n int) ([]string, error) {
+	return requestCandidates(ctx, tr, systemPrompt, prompt, input, model, n)
+}
+
+// Pick runs the picker with keys. Regenerating returns regenerated.
+func Pick(ctx context.Context, keys string, candidates, regenerated []string) (string, bool, error) {
+	p := &picker{keys: bufio.NewReader(strings.NewReader(keys)), out: io.Discard}
+	return p.pick(ctx, candidates, func(ctx context.Context) ([]string, error) {
+		return regenerated, nil
+	})
 }
@@ -0,0 +1,90 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"slices"
+	"strings"
+)
+
+// gitOutput runs git with args and returns its standard output.
+func gitOutput(ctx context.Context, args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("git %s: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
+
+// currentBranch returns the name of the current Git branch.
+// It returns an empty string outside a repository or on a detached HEAD.
+func currentBranch(ctx context.Context) string {
+	out, err := gitOutput(ctx, "symbolic-ref", "--quiet", "--short", "HEAD")
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(out)
+}
+
+// gitDiff returns the output of "git diff args extra". The changes in white space are left out
+// to keep the diff small, unless nothing else changed: then the diff has them, so that
+// reindenting or removing trailing spaces is not taken for no changes.
+func gitDiff(ctx context.Context, args, extra []string) (string, error) {
+	diff, err := gitOutput(ctx, slices.Concat([]string{"diff", "-w"}, args, extra)...)
+	if err != nil || strings.TrimSpace(diff) != "" {
+		return diff, err
+	}
+	return gitOutput(ctx, slices.Concat([]string{"diff"}, args, extra)...)
+}
+
+// stagedDiff returns the diff of the staged changes, which -commit suggests a message for.
+func stagedDiff(ctx context.Context, extra []string) (string, error) {
+	return gitDiff(ctx, []string{"--staged"}, extra)
+}
+
+// workingTreeDiff returns the diff of the working tree against HEAD, including the staged
+// changes and the files added with "git add -N". Untracked files are not included.
+func workingTreeDiff(ctx context.Context, extra []string) (string, error) {
+	if _, err := gitOutput(ctx, "rev-parse", "--verify", "--quiet", "HEAD"); err != nil {
+		// Without a commit, there is nothing to compare with but the index.
+		staged, err := stagedDiff(ctx, extra)
+		if err != nil {
+			return "", err
+		}
+		unstaged, err := gitDiff(ctx, nil, extra)
+		if err != nil {
+			return "", err
+		}
+		return staged + unstaged, nil
+	}
+	return gitDiff(ctx, []string{"HEAD"}, extra)
+}
+
+// baseDiff returns the diff of the working tree against the merge base of base and HEAD,
+// that is, the changes of the current branch since it forked from base.
+func baseDiff(ctx context.Context, base string, extra []string) (string, error) {
+	// base is given by the user and must not be taken for an option of git.
+	out, err := gitOutput(ctx, "merge-base", "--end-of-options", base, "HEAD")
+	if err != nil {
+		return "", err
+	}
+	return gitDiff(ctx, []string{strings.TrimSpace(out)}, extra)
+}
+
+// gitRun runs git with args connected to the given streams.
+func gitRun(ctx context.Context, stdin io.Reader, stdout, stderr io.Writer, args ...string) error {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Stdin = stdin
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("git %s: %w", strings.Join(args, " "), err)
+	}
+	return nil
+}
@@ -0,0 +1,211 @@
+package cli_test
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	. "github.com/catatsuy/bento/internal/cli"
+)
+
+// setupRepo creates a Git repository with a commit of README.md on main in a temporary
+// directory and changes the working directory to it.
+func setupRepo(t *testing.T) string {
+	t.Helper()
+
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	setupConfig(t, "", "")
+
+	dir := t.TempDir()
+	t.Chdir(dir)
+	for _, kv := range [][2]string{
+		{"GIT_AUTHOR_NAME", "bento"}, {"GIT_AUTHOR_EMAIL", "bento@example.com"},
+		{"GIT_COMMITTER_NAME", "bento"}, {"GIT_COMMITTER_EMAIL", "bento@example.com"},
+		{"GIT_CONFIG_GLOBAL", os.DevNull}, {"GIT_CONFIG_NOSYSTEM", "1"},
+	} {
+		t.Setenv(kv[0], kv[1])
+	}
+
+	writeFile(t, "README.md", "# bento\n")
+	git(t, "init", "-q", "-b", "main")
+	git(t, "add", "README.md")
+	git(t, "commit", "-q", "-m", "Initial commit")
+	return dir
+}
+
+// git runs git in the working directory and returns its output.
+func git(t *testing.T, args ...string) string {
+	t.Helper()
+
+	out, err := exec.Command("git", args...).CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %v: %v: %s", args, err, out)
+	}
+	return string(out)
+}
+
+func writeFile(t *testing.T, name, content string) {
+	t.Helper()
+
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(name, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// runGit runs bento with a terminal on standard input and returns the input sent to the model.
+func runGit(t *testing.T, args ...string) (string, string, int) {
+	t.Helper()
+
+	m := newMockModel("suggestion")
+	_, errOut, status := runBento(t, m.translator(), env{terminal: true}, args...)
+	return m.last().input, errOut, status
+}
+
+func TestRun_gitCommit(t *testing.T) {
+	setupRepo(t)
+
+	writeFile(t, "README.md", "# bento\n\nStaged.\n")
+	git(t, "add", "README.md")
+	writeFile(t, "README.md", "# bento\n\nStaged.\nNot staged.\n")
+
+	input, errOut, status := runGit(t, "-commit")
+	if status != ExitCodeOK {
+		t.Fatalf("ExitStatus=%d, want %d: %s", status, ExitCodeOK, errOut)
+	}
+	if !strings.Contains(input, "+Staged.") || strings.Contains(input, "Not staged.") {
+		t.Errorf("expected the staged diff, got %q", input)
+	}
+}
+
+func TestRun_gitCommitNoChanges(t *testing.T) {
+	setupRepo(t)
+
+	writeFile(t, "README.md", "# bento\n\nNot staged.\n")
+
+	_, errOut, status := runGit(t, "-commit")
+	if status != ExitCodeFail {
+		t.Errorf("ExitStatus=%d, want %d", status, ExitCodeFail)
+	}
+	if !strings.Contains(errOut, "There are no staged changes") {
+		t.Errorf("unexpected error %q", errOut)
+	}
+}
+
+func TestRun_gitWhitespaceOnly(t *testing.T) {
+	setupRepo(t)
+
+	// Changes in white space alone are still changes.
+	writeFile(t, "README.md", "# bento  \n")
+	git(t, "add", "README.md")
+
+	for _, args := range [][]string{{"-commit"}, {"-branch"}} {
+		input, errOut, status := runGit(t, args...)
+		if status != ExitCodeOK {
+			t.Fatalf("%v: ExitStatus=%d, want %d: %s", args, status, ExitCodeOK, errOut)
+		}
+		if !strings.Contains(input, "+# bento  ") {
+			t.Errorf("%v: expected the change in white space in the diff, got %q", args, input)
+		}
+	}
+}
+
+func TestRun_gitBranch(t *testing.T) {
+	setupRepo(t)
+
+	writeFile(t, "README.md", "# bento\n\nStaged.\n")
+	git(t, "add", "README.md")
+	writeFile(t, "README.md", "# bento\n\nStaged.\nNot staged.\n")
+	writeFile(t, "new.go", "package main\n")
+	git(t, "add", "-N", "new.go")
+	writeFile(t, "untracked.go", "package untracked\n")
+
+	input, errOut, status := runGit(t, "-branch")
+	if status != ExitCodeOK {
+		t.Fatalf("ExitStatus=%d, want %d: %s", status, ExitCodeOK, errOut)
+	}
+	for _, s := range []string{"+Staged.", "+Not staged.", "+package main"} {
+		if !strings.Contains(input, s) {
+			t.Errorf("expected %q in the diff, got %q", s, input)
+		}
+	}
+	if strings.Contains(input, "untracked") {
+		t.Errorf("expected untracked files to be excluded, got %q", input)
+	}
+}
+
+func TestRun_gitReviewBase(t *testing.T) {
+	setupRepo(t)
+
+	git(t, "switch", "-q", "-c", "feature")
+	writeFile(t, "feature.go", "package feature\n")
+	git(t, "add", "feature.go")
+	git(t, "commit", "-q", "-m", "Add feature")
+	writeFile(t, "docs/usage.md", "Usage\n")
+	git(t, "add", "-N", "docs/usage.md")
+
+	// Changes made on main after the branch forked are not part of the review.
+	git(t, "switch", "-q", "main")
+	writeFile(t, "main.go", "package main\n")
+	git(t, "add", "main.go")
+	git(t, "commit", "-q", "-m", "Change main")
+	git(t, "switch", "-q", "feature")
+
+	input, errOut, status := runGit(t, "-review", "-base", "main")
+	if status != ExitCodeOK {
+		t.Fatalf("ExitStatus=%d, want %d: %s", status, ExitCodeOK, errOut)
+	}
+	for _, s := range []string{"+package feature", "+Usage"} {
+		if !strings.Contains(input, s) {
+			t.Errorf("expected %q in the diff, got %q", s, input)
+		}
+	}
+	if strings.Contains(input, "package main") {
+		t.Errorf("expected the changes of main to be excluded, got %q", input)
+	}
+
+	// -diff-args is passed to git diff.
+	input, errOut, status = runGit(t, "-review", "-base", "main", "-diff-args", "-- docs")
+	if status != ExitCodeOK {
+		t.Fatalf("ExitStatus=%d, want %d: %s", status, ExitCodeOK, errOut)
+	}
+	if !strings.Contains(input, "+Usage") || strings.Contains(input, "feature.go") {
+		t.Errorf("expected only the diff of docs, got %q", input)
+	}
+}
+
+func TestRun_gitInvalid(t *testing.T) {
+	setupRepo(t)
+
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"base without review", []string{"-commit", "-base", "main"}, "can only be used with '-review'"},
+		{"diff args with file", []string{"-review", "-diff-args", "--stat", "-file", "README.md"}, "when bento runs git diff"},
+		{"unknown base", []string{"-review", "-base", "missing"}, "git merge-base --end-of-options missing HEAD"},
+		{"option as base", []string{"-review", "-base", "--output=out.diff"}, "git merge-base --end-of-options --output=out.diff HEAD"},
+		{"no changes", []string{"-branch"}, "There are no changes"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, errOut, status := runGit(t, tt.args...)
+			if status != ExitCodeFail {
+				t.Errorf("ExitStatus=%d, want %d", status, ExitCodeFail)
+			}
+			if !strings.Contains(errOut, tt.want) {
+				t.Errorf("expected %q in the error, got %q", tt.want, errOut)
+			}
+		})
+	}
+	if _, err := os.Stat("out.diff"); err == nil {
+		t.Error("expected the base not to be taken for an option of git")
+	}
+}
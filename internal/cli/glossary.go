@@ -0,0 +1,203 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// glossaryEntry is a line of a glossary file.
+type glossaryEntry struct {
+	Source string
+	// Target is the translation of Source. If it is empty, Source must not be translated.
+	Target string
+	Note   string
+}
+
+// rendering returns the text expected in the response for the entry.
+func (e glossaryEntry) rendering() string {
+	if e.Target == "" {
+		return e.Source
+	}
+	return e.Target
+}
+
+// loadGlossary reads a tab-separated glossary file with the source term, the target term
+// and an optional note on each line. A line without a target term lists a term that must
+// not be translated. Blank lines and lines starting with # are ignored.
+func loadGlossary(path string) ([]glossaryEntry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []glossaryEntry
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Split(line, "\t")
+		if len(fields) > 3 {
+			return nil, fmt.Errorf("%s:%d: expected a source term, a target term and an optional note separated by tabs", path, n)
+		}
+		e := glossaryEntry{Source: strings.TrimSpace(fields[0])}
+		if len(fields) >= 2 {
+			e.Target = strings.TrimSpace(fields[1])
+		}
+		if len(fields) == 3 {
+			e.Note = strings.TrimSpace(fields[2])
+		}
+		if e.Source == "" {
+			return nil, fmt.Errorf("%s:%d: the source term is empty", path, n)
+		}
+		entries = append(entries, e)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return entries, nil
+}
+
+// containsTerm reports whether text contains term, ignoring case. A term starting or
+// ending with a letter or a digit must not be part of a longer word, so that "API"
+// does not match "rapid". Scripts without spaces between words are matched anywhere.
+func containsTerm(text, term string) bool {
+	text, term = strings.ToLower(text), strings.ToLower(term)
+	first, _ := utf8.DecodeRuneInString(term)
+	last, _ := utf8.DecodeLastRuneInString(term)
+	for offset := 0; ; {
+		i := strings.Index(text[offset:], term)
+		if i < 0 {
+			return false
+		}
+		start, end := offset+i, offset+i+len(term)
+		before, _ := utf8.DecodeLastRuneInString(text[:start])
+		after, _ := utf8.DecodeRuneInString(text[end:])
+		if !(isWordRune(first) && start > 0 && isWordRune(before)) && !(isWordRune(last) && end < len(text) && isWordRune(after)) {
+			return true
+		}
+		offset = start + 1
+	}
+}
+
+// isWordRune reports whether r is part of words separated by spaces.
+func isWordRune(r rune) bool {
+	return r < utf8.RuneSelf && (unicode.IsLetter(r) || unicode.IsDigit(r))
+}
+
+// glossaryTranslator adds the glossary entries used in the input to the system prompt,
+// and warns when the response does not render a term as specified.
+type glossaryTranslator struct {
+	Translator
+
+	entries []glossaryEntry
+
+	// mu serializes the warnings of concurrent requests.
+	mu        sync.Mutex
+	errStream io.Writer
+}
+
+// newGlossaryTranslator wraps tr to apply the glossary entries. Warnings are written to errStream.
+func newGlossaryTranslator(tr Translator, entries []glossaryEntry, errStream io.Writer) *glossaryTranslator {
+	return &glossaryTranslator{Translator: tr, entries: entries, errStream: errStream}
+}
+
+func (gt *glossaryTranslator) request(ctx context.Context, systemPrompt, prompt, input, model string) (string, error) {
+	used := gt.match(input)
+	text, err := gt.Translator.request(ctx, glossaryPrompt(systemPrompt, used), prompt, input, model)
+	if err != nil {
+		return "", err
+	}
+	gt.check(used, text)
+	return text, nil
+}
+
+func (gt *glossaryTranslator) requestStream(ctx context.Context, systemPrompt, prompt, input, model string, w io.Writer) error {
+	used := gt.match(input)
+	systemPrompt = glossaryPrompt(systemPrompt, used)
+
+	text, err := streamOrWrite(ctx, gt.Translator, systemPrompt, prompt, input, model, w)
+	if err != nil {
+		return err
+	}
+	gt.check(used, text)
+	return nil
+}
+
+func (gt *glossaryTranslator) requestCandidates(ctx context.Context, systemPrompt, prompt, input, model string, n int) ([]string, error) {
+	used := gt.match(input)
+	texts, err := requestCandidates(ctx, gt.Translator, glossaryPrompt(systemPrompt, used), prompt, input, model, n)
+	if err != nil {
+		return nil, err
+	}
+	for _, text := range texts {
+		gt.check(used, text)
+	}
+	return texts, nil
+}
+
+func (gt *glossaryTranslator) usage() Usage {
+	return usageOf(gt.Translator)
+}
+
+// match returns the entries whose source term appears in text.
+func (gt *glossaryTranslator) match(text string) []glossaryEntry {
+	var used []glossaryEntry
+	for _, e := range gt.entries {
+		if containsTerm(text, e.Source) {
+			used = append(used, e)
+		}
+	}
+	return used
+}
+
+// check warns about the entries not rendered as specified in text.
+func (gt *glossaryTranslator) check(used []glossaryEntry, text string) {
+	gt.mu.Lock()
+	defer gt.mu.Unlock()
+	for _, e := range used {
+		if containsTerm(text, e.rendering()) {
+			continue
+		}
+		if e.Target == "" {
+			fmt.Fprintf(gt.errStream, "Warning: The glossary term %q was translated although it must be kept as is.\n", e.Source)
+		} else {
+			fmt.Fprintf(gt.errStream, "Warning: The glossary term %q was not translated as %q.\n", e.Source, e.Target)
+		}
+	}
+}
+
+// glossaryPrompt appends the instructions for the used entries to systemPrompt.
+func glossaryPrompt(systemPrompt string, used []glossaryEntry) string {
+	if len(used) == 0 {
+		return systemPrompt
+	}
+
+	var b strings.Builder
+	b.WriteString(systemPrompt)
+	if systemPrompt != "" {
+		b.WriteString("\n\n")
+	}
+	b.WriteString("Use the following glossary for these terms:\n")
+	for _, e := range used {
+		if e.Target == "" {
+			fmt.Fprintf(&b, "- %q: keep as is, do not translate", e.Source)
+		} else {
+			fmt.Fprintf(&b, "- %q: translate as %q", e.Source, e.Target)
+		}
+		if e.Note != "" {
+			fmt.Fprintf(&b, " (%s)", e.Note)
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
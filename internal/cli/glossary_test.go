@@ -0,0 +1,83 @@
+package cli_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	. "github.com/catatsuy/bento/internal/cli"
+)
+
+func TestRun_glossary(t *testing.T) {
+	m := &mockModel{respond: func(r request) string {
+		switch {
+		case strings.Contains(r.input, "cluster"):
+			return "bento はクラスターを管理します。"
+		case strings.Contains(r.input, "node"):
+			return "各 node は API を使います。"
+		default:
+			return "ラピッド"
+		}
+	}}
+
+	input := "bento manages the cluster.\n\nEach node uses the API.\n\nrapid\n"
+	_, errOut := mustRunBento(t, m.translator(), env{stdin: input}, "-translate", "-language", "ja", "-chunk-by", "paragraph", "-limit", "30", "-concurrency", "2", "-glossary", "testdata/glossary.tsv")
+	systemPrompts := make(map[string]string)
+	for _, r := range m.calls() {
+		systemPrompts[r.input] = r.system
+	}
+
+	// Only the terms in a chunk are added to its system prompt.
+	expected := map[string]string{
+		"bento manages the cluster.\n\n": "Use the following glossary for these terms:\n" +
+			"- \"cluster\": translate as \"クラスター\" (infrastructure sense)\n" +
+			"- \"bento\": keep as is, do not translate\n",
+		"Each node uses the API.\n\n": "Use the following glossary for these terms:\n" +
+			"- \"node\": translate as \"ノード\"\n" +
+			"- \"API\": keep as is, do not translate (keep the acronym)\n",
+		"rapid\n": "",
+	}
+	for text, systemPrompt := range expected {
+		if systemPrompts[text] != systemPrompt {
+			t.Errorf("system prompt for %q: expected %q, got %q", text, systemPrompt, systemPrompts[text])
+		}
+	}
+
+	// "node" was not rendered as "ノード" in the second chunk.
+	expectedWarnings := "Warning: The glossary term \"node\" was not translated as \"ノード\".\n"
+	if errOut != expectedWarnings {
+		t.Errorf("expected the warnings %q, got %q", expectedWarnings, errOut)
+	}
+}
+
+func TestRun_glossaryWithSystemPrompt(t *testing.T) {
+	m := newMockModel("API を呼ぶ")
+	_, errOut := mustRunBento(t, m.translator(), env{stdin: "Call the api\n"}, "-translate", "-system", "You are a translator.", "-glossary", "testdata/glossary.tsv")
+	systemPrompt := m.last().system
+
+	expected := "You are a translator.\n\nUse the following glossary for these terms:\n- \"API\": keep as is, do not translate (keep the acronym)\n"
+	if systemPrompt != expected {
+		t.Errorf("expected %q, got %q", expected, systemPrompt)
+	}
+	if errOut != "" {
+		t.Errorf("expected no warnings, got %q", errOut)
+	}
+}
+
+func TestRun_glossaryInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "glossary.tsv")
+	if err := os.WriteFile(path, []byte("a\tb\tc\td\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, errOut, status := runBento(t, &MockTranslator{}, env{stdin: "text\n"}, "-translate", "-glossary", path)
+	if status != ExitCodeFail {
+		t.Errorf("ExitStatus=%d, want %d", status, ExitCodeFail)
+	}
+
+	expected := path + ":1: expected a source term"
+	if !strings.Contains(errOut, expected) {
+		t.Errorf("Output=%q, want %q", errOut, expected)
+	}
+}
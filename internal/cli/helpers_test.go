@@ -0,0 +1,119 @@
+package cli_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	. "github.com/catatsuy/bento/internal/cli"
+)
+
+// TestMain keeps the tests from reading the config file and the BENTO_* environment variables
+// of the user, which would change the defaults of the flags.
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "bento-test-")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	for _, kv := range os.Environ() {
+		if key, _, _ := strings.Cut(kv, "="); strings.HasPrefix(key, "BENTO_") {
+			os.Unsetenv(key)
+		}
+	}
+	os.Setenv("BENTO_CONFIG", filepath.Join(dir, "missing.toml"))
+
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// request is a request received by a mockModel.
+type request struct {
+	system, prompt, input, model string
+}
+
+// mockModel is a model that returns its responses in order, repeating the last one,
+// or the responses of respond if it is set. It records the requests and is safe for concurrent use.
+type mockModel struct {
+	responses []string
+	respond   func(r request) string
+
+	mu       sync.Mutex
+	requests []request
+}
+
+// newMockModel returns a mockModel returning responses.
+func newMockModel(responses ...string) *mockModel {
+	return &mockModel{responses: responses}
+}
+
+// translator returns the Translator to pass to NewCLI.
+func (m *mockModel) translator() *MockTranslator {
+	return &MockTranslator{
+		TranslateTextFunc: func(ctx context.Context, systemPrompt, prompt, text, model string) (string, error) {
+			m.mu.Lock()
+			defer m.mu.Unlock()
+			r := request{systemPrompt, prompt, text, model}
+			m.requests = append(m.requests, r)
+			if m.respond != nil {
+				return m.respond(r), nil
+			}
+			return m.responses[min(len(m.requests), len(m.responses))-1], nil
+		},
+	}
+}
+
+// calls returns the requests received so far.
+func (m *mockModel) calls() []request {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]request(nil), m.requests...)
+}
+
+// last returns the last request received, or the zero request if there is none.
+func (m *mockModel) last() request {
+	calls := m.calls()
+	if len(calls) == 0 {
+		return request{}
+	}
+	return calls[len(calls)-1]
+}
+
+// env is the terminal bento runs in.
+type env struct {
+	// stdin is piped in, or read as the keys typed in the terminal if terminal is set.
+	stdin string
+	// terminal makes standard input a terminal.
+	terminal bool
+	// stdoutTerminal makes standard output a terminal.
+	stdoutTerminal bool
+}
+
+// runBento runs "bento args..." with tr in e and returns the output, the standard error and the status.
+func runBento(t *testing.T, tr Translator, e env, args ...string) (string, string, int) {
+	t.Helper()
+
+	outStream, errStream := new(bytes.Buffer), new(bytes.Buffer)
+	cl := NewCLI(outStream, errStream, strings.NewReader(e.stdin), tr, e.terminal)
+	cl.SetStdoutTerminal(e.stdoutTerminal)
+
+	status := cl.Run(append([]string{"bento"}, args...))
+	return outStream.String(), errStream.String(), status
+}
+
+// mustRunBento is like runBento but fails the test unless bento succeeds.
+func mustRunBento(t *testing.T, tr Translator, e env, args ...string) (string, string) {
+	t.Helper()
+
+	out, errOut, status := runBento(t, tr, e, args...)
+	if status != ExitCodeOK {
+		t.Fatalf("ExitStatus=%d, want %d: %s", status, ExitCodeOK, errOut)
+	}
+	return out, errOut
+}
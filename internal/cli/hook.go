@@ -0,0 +1,198 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// DefaultHookTimeout is the default time the prepare-commit-msg hook waits for a commit message.
+const DefaultHookTimeout = 20 * time.Second
+
+// hookName is the name of the hook installed by "bento hook install".
+const hookName = "prepare-commit-msg"
+
+// hookMarker is the line that tells the hooks installed by bento from the others.
+const hookMarker = "# Installed by \"bento hook install\"."
+
+// hookScript is the prepare-commit-msg hook. It does nothing if bento is not found,
+// so that committing never depends on bento.
+const hookScript = `#!/bin/sh
+` + hookMarker + ` Remove it with "bento hook uninstall".
+# It pre-fills the commit message from the staged changes unless a message is given.
+command -v bento >/dev/null 2>&1 || exit 0
+exec bento hook run -timeout %s "$@"
+`
+
+// runHook runs "bento hook <command>".
+func (c *CLI) runHook(args []string) int {
+	if len(args) > 0 {
+		switch args[0] {
+		case "install":
+			return c.installHook(args[1:])
+		case "uninstall":
+			return c.uninstallHook(args[1:])
+		case "run":
+			return c.runCommitMsgHook(args[1:])
+		}
+	}
+	fmt.Fprintf(c.errStream, "Error: Usage: bento hook install [-timeout duration] [-force] | bento hook uninstall\n")
+	return ExitCodeFail
+}
+
+// hookPath returns the path of the prepare-commit-msg hook of the current repository.
+// git resolves core.hooksPath and the hooks directory shared by worktrees.
+func hookPath(ctx context.Context) (string, error) {
+	out, err := gitOutput(ctx, "rev-parse", "--git-path", "hooks")
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(strings.TrimSpace(out), hookName), nil
+}
+
+// installHook writes the prepare-commit-msg hook. A hook not installed by bento is replaced only with -force.
+func (c *CLI) installHook(args []string) int {
+	var (
+		timeout time.Duration
+		force   bool
+	)
+	flags := flag.NewFlagSet("bento hook install", flag.ContinueOnError)
+	flags.SetOutput(c.errStream)
+	flags.DurationVar(&timeout, "timeout", DefaultHookTimeout, "Maximum time to wait for a commit message before committing without one")
+	flags.BoolVar(&force, "force", false, "Replace a prepare-commit-msg hook not installed by bento")
+	if err := flags.Parse(args); err != nil {
+		fmt.Fprintf(c.errStream, "Error: %v\n", err)
+		return ExitCodeFail
+	}
+	if timeout <= 0 {
+		fmt.Fprintf(c.errStream, "Error: The '-timeout' option must be positive.\n")
+		return ExitCodeFail
+	}
+
+	path, err := hookPath(context.Background())
+	if err != nil {
+		fmt.Fprintf(c.errStream, "Error: %v\n", err)
+		return ExitCodeFail
+	}
+	if b, err := os.ReadFile(path); err == nil && !bytes.Contains(b, []byte(hookMarker)) && !force {
+		fmt.Fprintf(c.errStream, "Error: %s already exists. Use '-force' to replace it.\n", path)
+		return ExitCodeFail
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		fmt.Fprintf(c.errStream, "Error: %v\n", err)
+		return ExitCodeFail
+	}
+	if err := writeFileAtomic(path, fmt.Appendf(nil, hookScript, timeout)); err != nil {
+		fmt.Fprintf(c.errStream, "Error: %v\n", err)
+		return ExitCodeFail
+	}
+	if err := os.Chmod(path, 0o755); err != nil {
+		fmt.Fprintf(c.errStream, "Error: %v\n", err)
+		return ExitCodeFail
+	}
+	fmt.Fprintf(c.outStream, "Installed the %s hook in %s\n", hookName, path)
+	return ExitCodeOK
+}
+
+// uninstallHook removes the prepare-commit-msg hook installed by bento.
+func (c *CLI) uninstallHook(args []string) int {
+	if len(args) > 0 {
+		fmt.Fprintf(c.errStream, "Error: Usage: bento hook uninstall\n")
+		return ExitCodeFail
+	}
+
+	path, err := hookPath(context.Background())
+	if err != nil {
+		fmt.Fprintf(c.errStream, "Error: %v\n", err)
+		return ExitCodeFail
+	}
+	b, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		fmt.Fprintf(c.errStream, "Error: There is no %s hook in %s.\n", hookName, filepath.Dir(path))
+		return ExitCodeFail
+	}
+	if err != nil {
+		fmt.Fprintf(c.errStream, "Error: %v\n", err)
+		return ExitCodeFail
+	}
+	if !bytes.Contains(b, []byte(hookMarker)) {
+		fmt.Fprintf(c.errStream, "Error: %s was not installed by bento. Remove it yourself.\n", path)
+		return ExitCodeFail
+	}
+	if err := os.Remove(path); err != nil {
+		fmt.Fprintf(c.errStream, "Error: %v\n", err)
+		return ExitCodeFail
+	}
+	fmt.Fprintf(c.outStream, "Removed the %s hook from %s\n", hookName, path)
+	return ExitCodeOK
+}
+
+// runCommitMsgHook runs "bento hook run <file> [<source> [<commit>]]", called by the hook with its arguments.
+// It writes the suggested commit message to the top of the file only when git did not get a message,
+// with -m, -F, -c, a template or a merge. Failures and timeouts are warnings, so that committing is never blocked.
+func (c *CLI) runCommitMsgHook(args []string) int {
+	var timeout time.Duration
+	flags := flag.NewFlagSet("bento hook run", flag.ContinueOnError)
+	flags.SetOutput(c.errStream)
+	flags.DurationVar(&timeout, "timeout", DefaultHookTimeout, "Maximum time to wait for a commit message")
+	if err := flags.Parse(args); err != nil {
+		fmt.Fprintf(c.errStream, "Error: %v\n", err)
+		return ExitCodeOK
+	}
+	if flags.NArg() == 0 {
+		fmt.Fprintf(c.errStream, "Error: Usage: bento hook run [-timeout duration] <file> [<source> [<commit>]]\n")
+		return ExitCodeOK
+	}
+	file := flags.Arg(0)
+	if flags.Arg(1) != "" {
+		return ExitCodeOK
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	// Without staged changes, git does not commit, so no message is requested.
+	if diff, err := stagedDiff(ctx, nil); err != nil || strings.TrimSpace(diff) == "" {
+		return ExitCodeOK
+	}
+
+	// bento runs as "bento -commit" in a terminal, which reads the staged changes itself.
+	var out bytes.Buffer
+	hc := &CLI{
+		appVersion:      c.appVersion,
+		outStream:       &out,
+		errStream:       c.errStream,
+		inputStream:     c.inputStream,
+		translator:      c.translator,
+		isStdinTerminal: true,
+	}
+	status := hc.run(ctx, []string{"bento", "-commit"})
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		fmt.Fprintf(c.errStream, "Warning: bento did not suggest a commit message within %s.\n", timeout)
+		return ExitCodeOK
+	}
+	msg := strings.TrimSpace(out.String())
+	if status != ExitCodeOK || msg == "" {
+		fmt.Fprintf(c.errStream, "Warning: bento could not suggest a commit message.\n")
+		return ExitCodeOK
+	}
+
+	// git wrote the comments explaining how to write the message, which are kept below it.
+	current, err := os.ReadFile(file)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		fmt.Fprintf(c.errStream, "Warning: %v\n", err)
+		return ExitCodeOK
+	}
+	if err := os.WriteFile(file, append([]byte(msg+"\n"), current...), 0o644); err != nil {
+		fmt.Fprintf(c.errStream, "Warning: %v\n", err)
+	}
+	return ExitCodeOK
+}
@@ -0,0 +1,178 @@
+package cli_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	. "github.com/catatsuy/bento/internal/cli"
+)
+
+// runHook runs "bento hook" with args and the suggestion returned by the model.
+func runHook(t *testing.T, suggestion string, args ...string) (string, string, int) {
+	t.Helper()
+
+	return runBento(t, newMockModel(suggestion).translator(), env{}, append([]string{"hook"}, args...)...)
+}
+
+func TestRun_hookInstall(t *testing.T) {
+	dir := setupRepo(t)
+	hook := filepath.Join(dir, ".git", "hooks", "prepare-commit-msg")
+
+	if _, errOut, status := runHook(t, "", "install", "-timeout", "5s"); status != ExitCodeOK {
+		t.Fatalf("ExitStatus=%d, want %d: %s", status, ExitCodeOK, errOut)
+	}
+	fi, err := os.Stat(hook)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.Mode().Perm()&0o100 == 0 {
+		t.Errorf("expected the hook to be executable, got %v", fi.Mode())
+	}
+	b, err := os.ReadFile(hook)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), `bento hook run -timeout 5s "$@"`) {
+		t.Errorf("unexpected hook:\n%s", b)
+	}
+
+	// The hook installed by bento can be installed again.
+	if _, errOut, status := runHook(t, "", "install"); status != ExitCodeOK {
+		t.Fatalf("ExitStatus=%d, want %d: %s", status, ExitCodeOK, errOut)
+	}
+
+	if _, errOut, status := runHook(t, "", "uninstall"); status != ExitCodeOK {
+		t.Fatalf("ExitStatus=%d, want %d: %s", status, ExitCodeOK, errOut)
+	}
+	if _, err := os.Stat(hook); !os.IsNotExist(err) {
+		t.Errorf("expected the hook to be removed, got %v", err)
+	}
+	if _, _, status := runHook(t, "", "uninstall"); status != ExitCodeFail {
+		t.Errorf("ExitStatus=%d, want %d without a hook", status, ExitCodeFail)
+	}
+}
+
+func TestRun_hookInstallHooksPath(t *testing.T) {
+	dir := setupRepo(t)
+	git(t, "config", "core.hooksPath", ".githooks")
+
+	if _, errOut, status := runHook(t, "", "install"); status != ExitCodeOK {
+		t.Fatalf("ExitStatus=%d, want %d: %s", status, ExitCodeOK, errOut)
+	}
+	if _, err := os.Stat(filepath.Join(dir, ".githooks", "prepare-commit-msg")); err != nil {
+		t.Errorf("expected the hook in core.hooksPath: %v", err)
+	}
+}
+
+func TestRun_hookInstallExisting(t *testing.T) {
+	dir := setupRepo(t)
+	hook := filepath.Join(dir, ".git", "hooks", "prepare-commit-msg")
+	writeFile(t, hook, "#!/bin/sh\necho mine\n")
+
+	if _, _, status := runHook(t, "", "install"); status != ExitCodeFail {
+		t.Errorf("ExitStatus=%d, want %d for an existing hook", status, ExitCodeFail)
+	}
+	if _, _, status := runHook(t, "", "uninstall"); status != ExitCodeFail {
+		t.Errorf("ExitStatus=%d, want %d for a hook not installed by bento", status, ExitCodeFail)
+	}
+	if b, _ := os.ReadFile(hook); string(b) != "#!/bin/sh\necho mine\n" {
+		t.Errorf("expected the hook to be kept, got %q", b)
+	}
+
+	if _, errOut, status := runHook(t, "", "install", "-force"); status != ExitCodeOK {
+		t.Fatalf("ExitStatus=%d, want %d: %s", status, ExitCodeOK, errOut)
+	}
+	if b, _ := os.ReadFile(hook); !strings.Contains(string(b), "bento hook run") {
+		t.Errorf("expected the hook to be replaced, got %q", b)
+	}
+}
+
+func TestRun_hookRun(t *testing.T) {
+	setupRepo(t)
+	writeFile(t, "main.go", "package main\n")
+	git(t, "add", "main.go")
+
+	const template = "\n# Please enter the commit message for your changes.\n"
+	msgFile := filepath.Join(t.TempDir(), "COMMIT_EDITMSG")
+
+	writeFile(t, msgFile, template)
+	if _, errOut, status := runHook(t, "Add main.go", "run", msgFile); status != ExitCodeOK || errOut != "" {
+		t.Fatalf("ExitStatus=%d, want %d: %s", status, ExitCodeOK, errOut)
+	}
+	if b, _ := os.ReadFile(msgFile); string(b) != "Add main.go\n"+template {
+		t.Errorf("expected the message above the template, got %q", b)
+	}
+
+	// A message given with -m, -F, a template or a merge is kept.
+	for _, source := range []string{"message", "template", "merge", "squash", "commit"} {
+		writeFile(t, msgFile, template)
+		if _, errOut, status := runHook(t, "Add main.go", "run", msgFile, source); status != ExitCodeOK || errOut != "" {
+			t.Fatalf("%s: ExitStatus=%d, want %d: %s", source, status, ExitCodeOK, errOut)
+		}
+		if b, _ := os.ReadFile(msgFile); string(b) != template {
+			t.Errorf("%s: expected the file to be kept, got %q", source, b)
+		}
+	}
+}
+
+func TestRun_hookRunFailure(t *testing.T) {
+	setupRepo(t)
+	writeFile(t, "main.go", "package main\n")
+	git(t, "add", "main.go")
+	msgFile := filepath.Join(t.TempDir(), "COMMIT_EDITMSG")
+
+	// A slow model does not block committing.
+	mockTranslator := &MockTranslator{
+		TranslateTextFunc: func(ctx context.Context, systemPrompt, prompt, text, model string) (string, error) {
+			<-ctx.Done()
+			return "", ctx.Err()
+		},
+	}
+	if _, errOut, status := runBento(t, mockTranslator, env{}, "hook", "run", "-timeout", "50ms", msgFile); status != ExitCodeOK {
+		t.Errorf("ExitStatus=%d, want %d", status, ExitCodeOK)
+	} else if !strings.Contains(errOut, "did not suggest a commit message within 50ms") {
+		t.Errorf("expected a timeout warning, got %q", errOut)
+	}
+	if _, err := os.Stat(msgFile); !os.IsNotExist(err) {
+		t.Errorf("expected no message to be written, got %v", err)
+	}
+
+	// Without staged changes, nothing is requested.
+	git(t, "reset", "-q")
+	if _, errOut, status := runHook(t, "unused", "run", msgFile); status != ExitCodeOK || errOut != "" {
+		t.Errorf("ExitStatus=%d, want %d: %s", status, ExitCodeOK, errOut)
+	}
+	if _, err := os.Stat(msgFile); !os.IsNotExist(err) {
+		t.Errorf("expected no message to be written, got %v", err)
+	}
+}
+
+func TestRun_hookCommit(t *testing.T) {
+	dir := setupRepo(t)
+
+	// A fake bento on PATH records how the hook calls it.
+	bin := t.TempDir()
+	writeFile(t, filepath.Join(bin, "bento"), "#!/bin/sh\necho \"$@\" > \""+filepath.Join(dir, "args")+"\"\n")
+	if err := os.Chmod(filepath.Join(bin, "bento"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	if _, errOut, status := runHook(t, "", "install", "-timeout", "3s"); status != ExitCodeOK {
+		t.Fatalf("ExitStatus=%d, want %d: %s", status, ExitCodeOK, errOut)
+	}
+	writeFile(t, "main.go", "package main\n")
+	git(t, "add", "main.go")
+	git(t, "commit", "-q", "-m", "Add main.go")
+
+	b, err := os.ReadFile(filepath.Join(dir, "args"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := strings.TrimSpace(string(b)), "hook run -timeout 3s .git/COMMIT_EDITMSG message"; got != want {
+		t.Errorf("hook arguments=%q, want %q", got, want)
+	}
+}
@@ -0,0 +1,115 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/catatsuy/bento/internal/markdown"
+	"github.com/catatsuy/bento/internal/placeholder"
+)
+
+// markdownInstruction is added to the system prompt in Markdown mode so that the placeholders survive.
+const markdownInstruction = "The text is Markdown. Keep the Markdown syntax and the placeholders such as ⟦0⟧ unchanged."
+
+// markdownPart is a part of the document in Markdown mode.
+type markdownPart struct {
+	// text is the text kept unchanged, or the text sent to the model with the placeholders.
+	text string
+	// request is true if text is sent to the model.
+	request bool
+	// protected are the values of the placeholders in text.
+	protected []string
+	// lead and trail are the white space around text, which the model does not keep reliably.
+	lead, trail string
+}
+
+// markdownRequest processes Markdown input like multiRequest, but sends only the translatable text.
+// Front matter, code blocks, HTML, link targets and URLs are kept unchanged, and the document is
+// written with the same structure as the input.
+func (c *CLI) markdownRequest(ctx context.Context, systemPrompt, prompt, useModel string, opts multiOptions) error {
+	b, err := io.ReadAll(c.inputStream)
+	if err != nil {
+		return fmt.Errorf("error reading input: %w", err)
+	}
+
+	ch := opts.chunker
+	if ch == nil {
+		ch, _ = newChunker("markdown")
+	}
+
+	var parts []markdownPart
+	for _, seg := range markdown.Split(string(b)) {
+		if !seg.Translatable {
+			parts = append(parts, markdownPart{text: seg.Text})
+			continue
+		}
+		err := ch.chunk(strings.NewReader(seg.Text), opts.limit, func(chunk string) error {
+			body := strings.TrimSpace(chunk)
+			if body == "" {
+				parts = append(parts, markdownPart{text: chunk})
+				return nil
+			}
+			i := strings.Index(chunk, body)
+			text, protected := markdown.Protect(body)
+			parts = append(parts, markdownPart{
+				text:      text,
+				request:   true,
+				protected: protected,
+				lead:      chunk[:i],
+				trail:     chunk[i+len(body):],
+			})
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if systemPrompt != "" {
+		systemPrompt += "\n\n"
+	}
+	systemPrompt += markdownInstruction
+
+	// next is the index of the next part to write.
+	next := 0
+	writeLiterals := func() error {
+		for ; next < len(parts) && !parts[next].request; next++ {
+			if _, err := io.WriteString(c.outStream, parts[next].text); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	produce := func(send func(chunk string) error) error {
+		for _, p := range parts {
+			if !p.request {
+				continue
+			}
+			if err := send(p.text); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	consume := func(text string) error {
+		if err := writeLiterals(); err != nil {
+			return err
+		}
+		p := parts[next]
+		next++
+		restored, err := placeholder.Restore(strings.TrimSpace(text), p.protected)
+		if err != nil {
+			return fmt.Errorf("the response did not keep the Markdown: %w", err)
+		}
+		_, err = io.WriteString(c.outStream, p.lead+restored+p.trail)
+		return err
+	}
+
+	if err := c.requestChunks(ctx, systemPrompt, prompt, useModel, opts, produce, consume); err != nil {
+		return err
+	}
+	return writeLiterals()
+}
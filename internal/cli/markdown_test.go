@@ -0,0 +1,106 @@
+package cli_test
+
+import (
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+
+	. "github.com/catatsuy/bento/internal/cli"
+)
+
+// upperText upper-cases the text outside the placeholders, like a translation would change it.
+func upperText(text string) string {
+	re := regexp.MustCompile(`⟦\d+⟧`)
+	var b strings.Builder
+	last := 0
+	for _, loc := range re.FindAllStringIndex(text, -1) {
+		b.WriteString(strings.ToUpper(text[last:loc[0]]))
+		b.WriteString(text[loc[0]:loc[1]])
+		last = loc[1]
+	}
+	b.WriteString(strings.ToUpper(text[last:]))
+	return b.String()
+}
+
+func TestRun_markdownRoundTrip(t *testing.T) {
+	src, err := os.ReadFile("testdata/document.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A model that returns the text as is gives back the document unchanged.
+	m := &mockModel{respond: func(r request) string { return r.input }}
+	out, _ := mustRunBento(t, m.translator(), env{terminal: true}, "-translate", "-markdown", "-file", "testdata/document.md")
+
+	if out != string(src) {
+		t.Errorf("expected the document unchanged, got:\n%s", out)
+	}
+	if calls := m.calls(); len(calls) == 0 || !strings.Contains(calls[0].system, "⟦0⟧") {
+		t.Errorf("expected the system prompt to mention the placeholders, got %q", calls)
+	}
+}
+
+func TestRun_markdownKeepsCode(t *testing.T) {
+	src, err := os.ReadFile("testdata/document.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := &mockModel{respond: func(r request) string {
+		// Models do not keep the white space around the text reliably.
+		return "\n" + upperText(r.input) + "\n\n"
+	}}
+	out, _ := mustRunBento(t, m.translator(), env{terminal: true}, "-translate", "-markdown", "-limit", "100", "-concurrency", "4", "-file", "testdata/document.md")
+
+	for _, s := range []string{
+		"---\ntitle: Getting Started\ntags: [guide]\n---\n",
+		"# GETTING STARTED\n\nINSTALL THE TOOL WITH `go install` AND READ THE [DOCUMENTATION](https://example.com/docs \"Docs\").\n",
+		"```sh\n# comment that must not be translated\nbento -translate -file README.md\n```\n",
+		"- FIRST ITEM WITH <kbd>CTRL</kbd>\n- SECOND ITEM, SEE https://example.com/faq.\n",
+		"<div align=\"center\">\n  <img src=\"logo.png\">\n</div>\n",
+		"| FOO  | DOES ``a `b` c`` |\n",
+		"    indented code block\n",
+		"SEE [THE GUIDE][guide] AND <https://example.com>.\n\n[guide]: https://example.com/guide\n",
+	} {
+		if !strings.Contains(out, s) {
+			t.Errorf("expected the output to contain %q, got:\n%s", s, out)
+		}
+	}
+	if strings.Count(out, "\n") != strings.Count(string(src), "\n") {
+		t.Errorf("expected %d lines, got:\n%s", strings.Count(string(src), "\n"), out)
+	}
+
+	for _, r := range m.calls() {
+		for _, s := range []string{"```", "https://", "title:", "<div"} {
+			if strings.Contains(r.input, s) {
+				t.Errorf("expected %q not to be sent, got %q", s, r.input)
+			}
+		}
+	}
+}
+
+func TestRun_markdownLostPlaceholder(t *testing.T) {
+	m := newMockModel("translated without placeholders")
+	_, errOut, status := runBento(t, m.translator(), env{stdin: "Run `go test` now.\n"}, "-translate", "-markdown")
+	if status != ExitCodeFail {
+		t.Errorf("ExitStatus=%d, want %d", status, ExitCodeFail)
+	}
+
+	expected := "placeholder ⟦0⟧ is missing"
+	if !strings.Contains(errOut, expected) {
+		t.Errorf("Output=%q, want %q", errOut, expected)
+	}
+}
+
+func TestRun_markdownSingleMode(t *testing.T) {
+	_, errOut, status := runBento(t, &MockTranslator{}, env{}, "-markdown", "-prompt", "x")
+	if status != ExitCodeFail {
+		t.Errorf("ExitStatus=%d, want %d", status, ExitCodeFail)
+	}
+
+	expected := "'-markdown' option can only be used in multi mode"
+	if !strings.Contains(errOut, expected) {
+		t.Errorf("Output=%q, want %q", errOut, expected)
+	}
+}
@@ -0,0 +1,119 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/catatsuy/bento/internal/cache"
+)
+
+// DefaultPruneAge is the default age of the cached values removed by "bento cache prune".
+const DefaultPruneAge = 30 * 24 * time.Hour
+
+// defaultCacheDir returns the directory of the caches when cache_dir is not set.
+// An empty string disables the caches. It is a variable so that the tests
+// do not share the caches of the user.
+var defaultCacheDir = func() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "bento")
+}
+
+// translationMemory holds the responses to the chunks of multi mode, so that the unchanged
+// chunks of a document are not requested again when it is translated after an edit.
+type translationMemory struct {
+	store *cache.Store
+
+	language string
+	model    string
+	// context is hashed with the prompts. It holds what else shapes the prompts sent,
+	// such as the glossary and the template variables.
+	context string
+
+	// errStream receives a warning the first time a translation cannot be stored.
+	errStream io.Writer
+	warnOnce  sync.Once
+}
+
+// newTranslationMemory returns the translation memory in the translations directory of cacheDir.
+func newTranslationMemory(cacheDir, language, model, context string, errStream io.Writer) *translationMemory {
+	return &translationMemory{
+		store:     cache.New(filepath.Join(cacheDir, "translations")),
+		language:  language,
+		model:     model,
+		context:   context,
+		errStream: errStream,
+	}
+}
+
+// key returns the key of a chunk: the hash of the chunk, the language, the model and the hash of the prompts.
+func (m *translationMemory) key(systemPrompt, prompt, chunk string) string {
+	return cache.Key(cache.Key(chunk), m.language, m.model, cache.Key(systemPrompt, prompt, m.context))
+}
+
+// get returns the response stored for chunk, if any.
+func (m *translationMemory) get(systemPrompt, prompt, chunk string) (string, bool) {
+	key := m.key(systemPrompt, prompt, chunk)
+	text, ok := m.store.Get(key, 0)
+	if ok {
+		// Used translations are kept by "bento cache prune".
+		_ = m.store.Touch(key)
+	}
+	return text, ok
+}
+
+// put stores the response to chunk. A failure only disables the memory for the run.
+func (m *translationMemory) put(systemPrompt, prompt, chunk, text string) {
+	if err := m.store.Put(m.key(systemPrompt, prompt, chunk), text); err != nil {
+		m.warnOnce.Do(func() {
+			fmt.Fprintf(m.errStream, "Warning: Failed to store the translation memory: %v\n", err)
+		})
+	}
+}
+
+// runCache runs "bento cache <command>".
+func (c *CLI) runCache(args []string, cacheDir string) int {
+	if len(args) == 0 || args[0] != "prune" {
+		fmt.Fprintf(c.errStream, "Error: Usage: bento cache prune [-older-than duration]\n")
+		return ExitCodeFail
+	}
+
+	var olderThan time.Duration
+	flags := flag.NewFlagSet("bento cache prune", flag.ContinueOnError)
+	flags.SetOutput(c.errStream)
+	flags.DurationVar(&olderThan, "older-than", DefaultPruneAge, "Remove the cached responses not used for this duration (0 removes all of them)")
+	if err := flags.Parse(args[1:]); err != nil {
+		fmt.Fprintf(c.errStream, "Error: %v\n", err)
+		return ExitCodeFail
+	}
+	if olderThan < 0 {
+		fmt.Fprintf(c.errStream, "Error: The '-older-than' option must not be negative.\n")
+		return ExitCodeFail
+	}
+	if cacheDir == "" {
+		fmt.Fprintf(c.errStream, "Error: The cache directory is unknown. Set cache_dir or BENTO_CACHE_DIR.\n")
+		return ExitCodeFail
+	}
+
+	// Only the stores of bento are pruned, never the other files of the directory.
+	var files int
+	var size int64
+	for _, store := range []string{"responses", "translations"} {
+		res, err := cache.New(filepath.Join(cacheDir, store)).Prune(olderThan)
+		files += res.Files
+		size += res.Bytes
+		if err != nil {
+			fmt.Fprintf(c.errStream, "Error: %v\n", err)
+			return ExitCodeFail
+		}
+	}
+	fmt.Fprintf(c.outStream, "Removed %d cached responses (%d bytes) from %s\n", files, size, cacheDir)
+	return ExitCodeOK
+}
@@ -0,0 +1,142 @@
+package cli_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	. "github.com/catatsuy/bento/internal/cli"
+	"github.com/google/go-cmp/cmp"
+)
+
+// runTranslate runs bento with input and returns the output and the texts requested.
+func runTranslate(t *testing.T, input string, args ...string) (string, []string) {
+	t.Helper()
+
+	m := &mockModel{respond: func(r request) string { return strings.ToUpper(strings.TrimSpace(r.input)) }}
+	out, _ := mustRunBento(t, m.translator(), env{stdin: input}, args...)
+	var requested []string
+	for _, r := range m.calls() {
+		requested = append(requested, r.input)
+	}
+	return out, requested
+}
+
+func TestRun_translationMemory(t *testing.T) {
+	t.Setenv("BENTO_CACHE_DIR", t.TempDir())
+
+	input := "first paragraph\n\nsecond paragraph\n\nthird paragraph\n"
+	args := []string{"-translate", "-language", "ja", "-chunk-by", "paragraph", "-limit", "20"}
+	out, requested := runTranslate(t, input, args...)
+	if len(requested) != 3 {
+		t.Fatalf("expected 3 requests, got %q", requested)
+	}
+
+	// Only the edited paragraph is requested again, and the output is the same as without the memory.
+	edited := strings.Replace(input, "second", "2nd", 1)
+	cachedOut, requested := runTranslate(t, edited, args...)
+	if diff := cmp.Diff([]string{"2nd paragraph\n\n"}, requested); diff != "" {
+		t.Errorf("requested chunks mismatch (-expected +actual):\n%s", diff)
+	}
+	if expected := strings.Replace(out, "SECOND", "2ND", 1); cachedOut != expected {
+		t.Errorf("expected %q, got %q", expected, cachedOut)
+	}
+
+	// Another language or model does not use the translations.
+	_, requested = runTranslate(t, input, "-translate", "-language", "fr", "-chunk-by", "paragraph", "-limit", "20")
+	if len(requested) != 3 {
+		t.Errorf("expected 3 requests for another language, got %q", requested)
+	}
+	_, requested = runTranslate(t, input, append(args, "-model", "other")...)
+	if len(requested) != 3 {
+		t.Errorf("expected 3 requests for another model, got %q", requested)
+	}
+
+	_, requested = runTranslate(t, input, append(args, "-no-cache")...)
+	if len(requested) != 3 {
+		t.Errorf("expected 3 requests with -no-cache, got %q", requested)
+	}
+}
+
+func TestRun_translationMemoryPrompt(t *testing.T) {
+	t.Setenv("BENTO_CACHE_DIR", t.TempDir())
+
+	input := "hello\n"
+	runTranslate(t, input, "-multi", "-prompt", "Translate:")
+
+	// A different prompt or template variable gives a different response.
+	_, requested := runTranslate(t, input, "-multi", "-prompt", "Summarize:")
+	if len(requested) != 1 {
+		t.Errorf("expected a request for another prompt, got %q", requested)
+	}
+	_, requested = runTranslate(t, input, "-multi", "-prompt", "Translate:", "-var", "tone=formal")
+	if len(requested) != 1 {
+		t.Errorf("expected a request for another variable, got %q", requested)
+	}
+	_, requested = runTranslate(t, input, "-multi", "-prompt", "Translate:")
+	if len(requested) != 0 {
+		t.Errorf("expected no request for the same prompt, got %q", requested)
+	}
+}
+
+func TestRun_translationMemoryContext(t *testing.T) {
+	setupRepo(t)
+	t.Setenv("BENTO_CACHE_DIR", t.TempDir())
+
+	// The servers of the openai-compatible backend do not share translations.
+	input := "hello\n"
+	args := []string{"-multi", "-prompt", "Translate:", "-backend", "openai-compatible", "-model", "llama3"}
+	runTranslate(t, input, append(args, "-base-url", "http://localhost:11434/v1")...)
+	_, requested := runTranslate(t, input, append(args, "-base-url", "http://localhost:8080/v1")...)
+	if len(requested) != 1 {
+		t.Errorf("expected a request for another server, got %q", requested)
+	}
+
+	// A prompt referring to the branch is not reused on another branch.
+	args = []string{"-multi", "-prompt", "Translate the text of {{.Branch}}:"}
+	runTranslate(t, input, args...)
+	git(t, "switch", "-q", "-c", "feature")
+	if _, requested := runTranslate(t, input, args...); len(requested) != 1 {
+		t.Errorf("expected a request on another branch, got %q", requested)
+	}
+	if _, requested := runTranslate(t, input, args...); len(requested) != 0 {
+		t.Errorf("expected no request on the same branch, got %q", requested)
+	}
+}
+
+func TestRun_cachePrune(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("BENTO_CACHE_DIR", dir)
+
+	runTranslate(t, "one\ntwo\n", "-translate", "-limit", "4")
+	other := filepath.Join(dir, "translations", "notes.txt")
+	writeFile(t, other, "not a translation\n")
+
+	// The translations were just used.
+	if out, _ := mustRunBento(t, nil, env{}, "cache", "prune"); !strings.HasPrefix(out, "Removed 0 cached responses") {
+		t.Errorf("unexpected output %q", out)
+	}
+
+	if out, _ := mustRunBento(t, nil, env{}, "cache", "prune", "-older-than", "0"); !strings.HasPrefix(out, "Removed 2 cached responses") {
+		t.Errorf("unexpected output %q", out)
+	}
+	if _, err := os.Stat(other); err != nil {
+		t.Errorf("expected the other files of the cache directory to be kept, got %v", err)
+	}
+
+	_, requested := runTranslate(t, "one\ntwo\n", "-translate", "-limit", "4")
+	if len(requested) != 2 {
+		t.Errorf("expected 2 requests after pruning, got %q", requested)
+	}
+}
+
+func TestRun_cacheInvalid(t *testing.T) {
+	_, errOut, status := runBento(t, nil, env{}, "cache")
+	if status != ExitCodeFail {
+		t.Errorf("ExitStatus=%d, want %d", status, ExitCodeFail)
+	}
+	if !strings.Contains(errOut, "Usage: bento cache prune") {
+		t.Errorf("unexpected error %q", errOut)
+	}
+}
@@ -0,0 +1,177 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// multiOptions are the options of multi mode.
+type multiOptions struct {
+	// chunker splits the input. If nil, the input is split at lines.
+	chunker *chunker
+	// limit is the maximum size of a chunk measured by the chunker.
+	limit int
+	// concurrency is the maximum number of requests in flight.
+	concurrency int
+	// rate is the maximum number of requests started per second. 0 means no limit.
+	rate float64
+	// memory holds the responses to the chunks translated before. If nil, every chunk is requested.
+	memory *translationMemory
+}
+
+// chunkResult is the response to a chunk.
+type chunkResult struct {
+	text string
+	err  error
+}
+
+// multiRequest processes multi-line input in chunks.
+// Up to opts.concurrency chunks are requested in parallel, and the responses are written in the order of the input.
+// The first error cancels the outstanding requests.
+func (c *CLI) multiRequest(ctx context.Context, systemPrompt, prompt, useModel string, opts multiOptions) error {
+	ch := opts.chunker
+	if ch == nil {
+		ch, _ = newChunker(DefaultChunkBy)
+	}
+
+	produce := func(send func(chunk string) error) error {
+		return ch.chunk(c.inputStream, opts.limit, send)
+	}
+	return c.requestChunks(ctx, systemPrompt, prompt, useModel, opts, produce, func(text string) error {
+		_, err := fmt.Fprintf(c.outStream, "%s\n", text)
+		return err
+	})
+}
+
+// requestChunks requests each chunk sent by produce and calls consume with the responses in the order of the chunks.
+// Up to opts.concurrency chunks are requested in parallel, at most opts.rate per second.
+// Chunks found in opts.memory are not requested. The first error cancels the outstanding requests.
+func (c *CLI) requestChunks(ctx context.Context, systemPrompt, prompt, useModel string, opts multiOptions,
+	produce func(send func(chunk string) error) error, consume func(text string) error) error {
+	concurrency := max(opts.concurrency, 1)
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	// queue holds the pending results in the order of the input.
+	queue := make(chan chan chunkResult, concurrency)
+	sem := make(chan struct{}, concurrency)
+	limiter := newRateLimiter(opts.rate)
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	// readErr is set before queue is closed.
+	var readErr error
+	wg.Go(func() {
+		defer close(queue)
+
+		readErr = produce(func(chunk string) error {
+			if opts.memory != nil {
+				if text, ok := opts.memory.get(systemPrompt, prompt, chunk); ok {
+					res := make(chan chunkResult, 1)
+					res <- chunkResult{text: text}
+					select {
+					case queue <- res:
+						return nil
+					case <-ctx.Done():
+						return ctx.Err()
+					}
+				}
+			}
+
+			if err := limiter.wait(ctx); err != nil {
+				return err
+			}
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+
+			res := make(chan chunkResult, 1)
+			select {
+			case queue <- res:
+			case <-ctx.Done():
+				<-sem
+				return ctx.Err()
+			}
+
+			wg.Go(func() {
+				defer func() { <-sem }()
+				text, err := c.translator.request(ctx, systemPrompt, prompt, chunk, useModel)
+				if err != nil {
+					err = fmt.Errorf("failed to translate text: %w", err)
+					cancel(err)
+				} else if opts.memory != nil {
+					opts.memory.put(systemPrompt, prompt, chunk, text)
+				}
+				res <- chunkResult{text: text, err: err}
+			})
+			return nil
+		})
+		if readErr != nil {
+			cancel(readErr)
+		}
+	})
+
+	for res := range queue {
+		r := <-res
+		if r.err != nil {
+			// ctx is already canceled, so the producer and the outstanding requests stop.
+			// The cause is the first error, which may belong to a later chunk.
+			return context.Cause(ctx)
+		}
+		if err := consume(r.text); err != nil {
+			cancel(err)
+			return err
+		}
+	}
+
+	if readErr != nil {
+		return context.Cause(ctx)
+	}
+	return nil
+}
+
+// rateLimiter spaces out the start of requests. It is not safe for concurrent use.
+type rateLimiter struct {
+	interval time.Duration
+	next     time.Time
+}
+
+// newRateLimiter returns a rateLimiter allowing rate requests per second. A rate of 0 or less means no limit.
+func newRateLimiter(rate float64) *rateLimiter {
+	if rate <= 0 {
+		return &rateLimiter{}
+	}
+	return &rateLimiter{interval: time.Duration(float64(time.Second) / rate)}
+}
+
+// wait blocks until the next request may start or ctx is done.
+func (l *rateLimiter) wait(ctx context.Context) error {
+	if l.interval <= 0 {
+		return ctx.Err()
+	}
+
+	now := time.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	d := l.next.Sub(now)
+	l.next = l.next.Add(l.interval)
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,134 @@
+package cli_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "github.com/catatsuy/bento/internal/cli"
+)
+
+func TestMultiRequest_concurrentOrder(t *testing.T) {
+	var input, expected strings.Builder
+	for i := range 100 {
+		fmt.Fprintf(&input, "line %d\n", i)
+		fmt.Fprintf(&expected, "LINE %d\n\n", i)
+	}
+
+	const concurrency = 8
+	var inFlight, maxInFlight atomic.Int32
+	mockTranslator := &MockTranslator{
+		TranslateTextFunc: func(ctx context.Context, systemPrompt, prompt, text, model string) (string, error) {
+			n := inFlight.Add(1)
+			defer inFlight.Add(-1)
+			for {
+				m := maxInFlight.Load()
+				if n <= m || maxInFlight.CompareAndSwap(m, n) {
+					break
+				}
+			}
+			time.Sleep(time.Duration(rand.N(5)) * time.Millisecond)
+			return strings.ToUpper(text), nil
+		},
+	}
+
+	outStream, errStream := new(bytes.Buffer), new(bytes.Buffer)
+	cl := NewCLI(outStream, errStream, strings.NewReader(input.String()), mockTranslator, false)
+
+	err := cl.MultiRequestConcurrently(t.Context(), "", "", "test", 8, concurrency, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if outStream.String() != expected.String() {
+		t.Errorf("expected %q, but got %q", expected.String(), outStream.String())
+	}
+	if m := maxInFlight.Load(); m > concurrency {
+		t.Errorf("expected at most %d requests in flight, but got %d", concurrency, m)
+	}
+}
+
+func TestMultiRequest_cancelOnError(t *testing.T) {
+	var input strings.Builder
+	for i := range 50 {
+		fmt.Fprintf(&input, "line %d\n", i)
+	}
+
+	errFailed := errors.New("failed")
+	var canceled atomic.Int32
+	mockTranslator := &MockTranslator{
+		TranslateTextFunc: func(ctx context.Context, systemPrompt, prompt, text, model string) (string, error) {
+			switch text {
+			case "line 0\n":
+				return "ok", nil
+			case "line 3\n":
+				time.Sleep(time.Duration(rand.N(5)) * time.Millisecond)
+				return "", errFailed
+			}
+			// The other chunks only finish when they are canceled.
+			<-ctx.Done()
+			canceled.Add(1)
+			return "", ctx.Err()
+		},
+	}
+
+	outStream, errStream := new(bytes.Buffer), new(bytes.Buffer)
+	cl := NewCLI(outStream, errStream, strings.NewReader(input.String()), mockTranslator, false)
+
+	err := cl.MultiRequestConcurrently(t.Context(), "", "", "test", 8, 4, 0)
+	if !errors.Is(err, errFailed) {
+		t.Fatalf("expected %v, but got %v", errFailed, err)
+	}
+
+	if outStream.String() != "ok\n" {
+		t.Errorf("expected %q, but got %q", "ok\n", outStream.String())
+	}
+	if canceled.Load() == 0 {
+		t.Error("expected the outstanding requests to be canceled")
+	}
+}
+
+func TestMultiRequest_rate(t *testing.T) {
+	input := "a\nb\nc\nd\ne\n"
+
+	mockTranslator := &MockTranslator{
+		TranslateTextFunc: func(ctx context.Context, systemPrompt, prompt, text, model string) (string, error) {
+			return strings.TrimSpace(text), nil
+		},
+	}
+
+	outStream, errStream := new(bytes.Buffer), new(bytes.Buffer)
+	cl := NewCLI(outStream, errStream, strings.NewReader(input), mockTranslator, false)
+
+	start := time.Now()
+	err := cl.MultiRequestConcurrently(t.Context(), "", "", "test", 2, 5, 50)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// 5 requests at 50 requests per second start over at least 80ms.
+	if elapsed := time.Since(start); elapsed < 80*time.Millisecond {
+		t.Errorf("expected the requests to be spaced out, but they took %v", elapsed)
+	}
+	if outStream.String() != "a\nb\nc\nd\ne\n" {
+		t.Errorf("expected %q, but got %q", "a\nb\nc\nd\ne\n", outStream.String())
+	}
+}
+
+func TestRun_invalidConcurrency(t *testing.T) {
+	_, errOut, status := runBento(t, &MockTranslator{}, env{}, "-multi", "-concurrency", "0", "-prompt", "x")
+	if status != ExitCodeFail {
+		t.Errorf("ExitStatus=%d, want %d", status, ExitCodeFail)
+	}
+
+	expected := "'-concurrency' option must be at least 1"
+	if !strings.Contains(errOut, expected) {
+		t.Errorf("Output=%q, want %q", errOut, expected)
+	}
+}
@@ -0,0 +1,168 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// pickerKey is an action read from the keys of the picker.
+type pickerKey int
+
+const (
+	keyNone pickerKey = iota
+	keyUp
+	keyDown
+	keyChoose
+	keyEdit
+	keyRegenerate
+	keyQuit
+)
+
+// picker lets the user choose one of the candidates of -n with the arrow keys.
+type picker struct {
+	// keys is read in raw mode, one key at a time.
+	keys *bufio.Reader
+	out  io.Writer
+
+	// cooked switches the terminal to line input while a line is edited and returns
+	// the function switching it back to raw mode. It is nil if keys is not a terminal.
+	cooked func() (raw func())
+
+	// lines is the number of lines drawn, which are cleared before drawing again.
+	lines int
+}
+
+// pick returns the chosen candidate. ok is false if the user quits.
+// generate is called to replace the candidates when the user asks to regenerate them.
+func (p *picker) pick(ctx context.Context, candidates []string, generate func(ctx context.Context) ([]string, error)) (chosen string, ok bool, err error) {
+	selected := 0
+	for {
+		p.draw(candidates, selected)
+
+		key, index, err := p.readKey()
+		if err != nil {
+			p.clear()
+			return "", false, nil
+		}
+		switch key {
+		case keyUp:
+			selected = (selected + len(candidates) - 1) % len(candidates)
+		case keyDown:
+			selected = (selected + 1) % len(candidates)
+		case keyChoose:
+			if index >= 0 {
+				if index >= len(candidates) {
+					continue
+				}
+				selected = index
+			}
+			p.clear()
+			return candidates[selected], true, nil
+		case keyEdit:
+			if text := p.edit(candidates[selected]); text != "" {
+				candidates[selected] = text
+			}
+		case keyRegenerate:
+			fmt.Fprintf(p.out, "Regenerating...\r\n")
+			p.lines++
+			// Regenerated candidates must not come from the response cache.
+			regenerated, err := generate(withoutCache(ctx))
+			if err != nil {
+				p.clear()
+				return "", false, err
+			}
+			candidates, selected = regenerated, 0
+		case keyQuit:
+			p.clear()
+			return "", false, nil
+		}
+	}
+}
+
+// draw draws the candidates over the ones drawn before.
+func (p *picker) draw(candidates []string, selected int) {
+	p.clear()
+	fmt.Fprintf(p.out, "Choose a suggestion (↑/↓ move, Enter choose, e edit, r regenerate, q quit):\r\n")
+	for i, candidate := range candidates {
+		cursor := "  "
+		if i == selected {
+			cursor = "> "
+		}
+		// Only the first line of a multi-line candidate, such as a commit message with a body, is shown.
+		line, _, more := strings.Cut(candidate, "\n")
+		if more {
+			line += " …"
+		}
+		fmt.Fprintf(p.out, "%s%d. %s\r\n", cursor, i+1, line)
+	}
+	p.lines = len(candidates) + 1
+}
+
+// clear removes the lines drawn.
+func (p *picker) clear() {
+	if p.lines > 0 {
+		fmt.Fprintf(p.out, "\x1b[%dA\r\x1b[J", p.lines)
+	}
+	p.lines = 0
+}
+
+// edit reads a line replacing text. An empty line keeps text.
+func (p *picker) edit(text string) string {
+	p.clear()
+	if p.cooked != nil {
+		raw := p.cooked()
+		defer raw()
+	}
+	fmt.Fprintf(p.out, "Current: %s\r\nNew (empty to keep it): ", text)
+	line, err := readAnswer(p.keys)
+	if err != nil {
+		return ""
+	}
+	return line
+}
+
+// readKey reads a key. For a digit, index is the position of the candidate it chooses, and -1 otherwise.
+func (p *picker) readKey() (key pickerKey, index int, err error) {
+	b, err := p.keys.ReadByte()
+	if err != nil {
+		return keyNone, -1, err
+	}
+	switch {
+	case b == '\r' || b == '\n':
+		return keyChoose, -1, nil
+	case b >= '1' && b <= '9':
+		return keyChoose, int(b - '1'), nil
+	case b == 'k':
+		return keyUp, -1, nil
+	case b == 'j':
+		return keyDown, -1, nil
+	case b == 'e':
+		return keyEdit, -1, nil
+	case b == 'r':
+		return keyRegenerate, -1, nil
+	// Ctrl-C and Ctrl-D do not send signals in raw mode.
+	case b == 'q' || b == 0x03 || b == 0x04:
+		return keyQuit, -1, nil
+	case b == 0x1b:
+		// The arrow keys send "ESC [ A" and "ESC [ B" at once. A lone ESC quits.
+		if p.keys.Buffered() == 0 {
+			return keyQuit, -1, nil
+		}
+		seq := make([]byte, 2)
+		if _, err := io.ReadFull(p.keys, seq); err != nil {
+			return keyNone, -1, err
+		}
+		if seq[0] == '[' || seq[0] == 'O' {
+			switch seq[1] {
+			case 'A':
+				return keyUp, -1, nil
+			case 'B':
+				return keyDown, -1, nil
+			}
+		}
+	}
+	return keyNone, -1, nil
+}
@@ -0,0 +1,148 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// prTemplatePaths are the paths of the pull request template in a repository, in the order GitHub looks for it.
+var prTemplatePaths = []string{
+	".github/pull_request_template.md",
+	".github/PULL_REQUEST_TEMPLATE.md",
+	"pull_request_template.md",
+	"PULL_REQUEST_TEMPLATE.md",
+	"docs/pull_request_template.md",
+	"docs/PULL_REQUEST_TEMPLATE.md",
+}
+
+// pullRequest is the title and the description of a pull request suggested with -pr.
+type pullRequest struct {
+	Title string `json:"title"`
+	Body  string `json:"body"`
+}
+
+// prPrompt returns the prompt of -pr. The body follows template unless it is empty.
+func prPrompt(template, language string) string {
+	var b strings.Builder
+	b.WriteString(`Write the title and the description of a pull request from the provided commit messages of the branch, between <commits> and </commits>, and the Git diff of the branch against its base, between <diff> and </diff>.
+The title must be a single line within 72 characters summarizing the whole change. The description explains what changed and why, for the reviewers, without repeating the diff.
+`)
+	if template != "" {
+		b.WriteString("Write the description in Markdown following the pull request template below, filling in its sections, keeping its headings and check boxes, and removing its HTML comments:\n\n<template>\n")
+		b.WriteString(escapeTemplate(strings.TrimSpace(template)))
+		b.WriteString("\n</template>\n")
+	} else {
+		b.WriteString("Write the description in Markdown: a short summary, then the main changes as a list.\n")
+	}
+	if language != "" {
+		b.WriteString("Write the title and the description in " + language + ".\n")
+	}
+	b.WriteString(`Answer only with a JSON object with the string fields "title" and "body", where body is the description, without any additional text or formatting.
+
+`)
+	return b.String()
+}
+
+// findPRTemplate returns the pull request template of the current repository.
+// It returns "" if there is none or outside a repository.
+func findPRTemplate(ctx context.Context) (string, error) {
+	root, err := gitOutput(ctx, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return "", nil
+	}
+	for _, path := range prTemplatePaths {
+		b, err := os.ReadFile(filepath.Join(strings.TrimSpace(root), path))
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		return string(b), nil
+	}
+	return "", nil
+}
+
+// defaultBase returns the branch pull requests are merged into: the default branch of origin, or main or master.
+func defaultBase(ctx context.Context) (string, error) {
+	if out, err := gitOutput(ctx, "symbolic-ref", "--quiet", "--short", "refs/remotes/origin/HEAD"); err == nil {
+		return strings.TrimSpace(out), nil
+	}
+	for _, base := range []string{"main", "master"} {
+		if _, err := gitOutput(ctx, "rev-parse", "--verify", "--quiet", base); err == nil {
+			return base, nil
+		}
+	}
+	return "", errors.New("the base branch is unknown. Specify it with '-base'")
+}
+
+// prInput returns the commit messages of base..HEAD and the diff of the changes since HEAD forked from base.
+// It returns an empty string if there are no commits.
+func prInput(ctx context.Context, base string, extra []string) (string, error) {
+	// base is given by the user and must not be taken for an option of git.
+	if _, err := gitOutput(ctx, "rev-parse", "--verify", "--quiet", "--end-of-options", base+"^{commit}"); err != nil {
+		return "", fmt.Errorf("the base %q is not a commit", base)
+	}
+	commits, err := gitOutput(ctx, "log", "--reverse", "--format=commit %h%n%n%B", base+"..HEAD")
+	if err != nil {
+		return "", err
+	}
+	if strings.TrimSpace(commits) == "" {
+		return "", nil
+	}
+	diff, err := gitOutput(ctx, append([]string{"diff", "-w", base + "...HEAD"}, extra...)...)
+	if err != nil {
+		return "", err
+	}
+	return "<commits>\n" + strings.TrimSpace(commits) + "\n</commits>\n\n<diff>\n" + diff + "</diff>\n", nil
+}
+
+// parsePullRequest returns the pull request of a response. A response that is not the JSON object
+// asked for is read as Markdown whose first line is the title.
+func parsePullRequest(response string) pullRequest {
+	var pr pullRequest
+	text := strings.TrimSpace(response)
+	start, end := strings.Index(text, "{"), strings.LastIndex(text, "}")
+	if start >= 0 && end > start {
+		if err := json.Unmarshal([]byte(text[start:end+1]), &pr); err == nil && pr.Title != "" {
+			pr.Title = strings.TrimSpace(pr.Title)
+			pr.Body = strings.TrimSpace(pr.Body)
+			return pr
+		}
+	}
+
+	title, body, _ := strings.Cut(text, "\n")
+	return pullRequest{
+		Title: strings.TrimSpace(strings.TrimPrefix(title, "# ")),
+		Body:  strings.TrimSpace(body),
+	}
+}
+
+// writePullRequest writes pr as Markdown, the title as a heading followed by the body, or as JSON.
+func writePullRequest(w io.Writer, pr pullRequest, asJSON bool) error {
+	if !asJSON {
+		if pr.Body == "" {
+			_, err := fmt.Fprintf(w, "# %s\n", pr.Title)
+			return err
+		}
+		_, err := fmt.Fprintf(w, "# %s\n\n%s\n", pr.Title, pr.Body)
+		return err
+	}
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(pr); err != nil {
+		return err
+	}
+	_, err := w.Write(b.Bytes())
+	return err
+}
@@ -0,0 +1,133 @@
+package cli_test
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	. "github.com/catatsuy/bento/internal/cli"
+)
+
+// runPR runs bento -pr with the response of the model and returns the output, the standard error,
+// the status and the prompt and the input of the request.
+func runPR(t *testing.T, response string, stdin *string, args ...string) (string, string, int, string, string) {
+	t.Helper()
+
+	e := env{terminal: true}
+	if stdin != nil {
+		e = env{stdin: *stdin}
+	}
+	m := newMockModel(response)
+	out, errOut, status := runBento(t, m.translator(), e, append([]string{"-pr"}, args...)...)
+	return out, errOut, status, m.last().prompt, m.last().input
+}
+
+// setupBranch creates a feature branch with two commits on top of main.
+func setupBranch(t *testing.T) string {
+	t.Helper()
+
+	dir := setupRepo(t)
+	git(t, "switch", "-q", "-c", "feature")
+	writeFile(t, "main.go", "package main\n")
+	git(t, "add", "main.go")
+	git(t, "commit", "-q", "-m", "Add main.go", "-m", "It is the entry point.")
+	writeFile(t, "README.md", "# bento\n\nUsage\n")
+	git(t, "commit", "-q", "-a", "-m", "Describe the usage")
+	return dir
+}
+
+func TestRun_pr(t *testing.T) {
+	setupBranch(t)
+	writeFile(t, ".github/pull_request_template.md", "## Why\n\n<!-- Explain {{why}} -->\n")
+
+	response := "```json\n{\"title\": \"Add the entry point\", \"body\": \"## Why\\n\\nTo run <bento>.\"}\n```"
+	out, errOut, status, prompt, input := runPR(t, response, nil)
+	if status != ExitCodeOK {
+		t.Fatalf("ExitStatus=%d, want %d: %s", status, ExitCodeOK, errOut)
+	}
+	if want := "# Add the entry point\n\n## Why\n\nTo run <bento>.\n"; out != want {
+		t.Errorf("output=%q, want %q", out, want)
+	}
+
+	// The commits are in order, followed by the diff against main.
+	first, second := strings.Index(input, "Add main.go\n\nIt is the entry point."), strings.Index(input, "Describe the usage")
+	if !strings.HasPrefix(input, "<commits>\n") || first < 0 || second < first {
+		t.Errorf("expected the commit messages in order, got %q", input)
+	}
+	if !strings.Contains(input, "<diff>\n") || !strings.Contains(input, "+package main") || !strings.Contains(input, "+Usage") {
+		t.Errorf("expected the diff against main, got %q", input)
+	}
+	if !strings.Contains(prompt, "<!-- Explain {{why}} -->") {
+		t.Errorf("expected the template in the prompt, got %q", prompt)
+	}
+
+	out, _, _, _, _ = runPR(t, response, nil, "-json")
+	if want := "{\n  \"title\": \"Add the entry point\",\n  \"body\": \"## Why\\n\\nTo run <bento>.\"\n}\n"; out != want {
+		t.Errorf("JSON output=%q, want %q", out, want)
+	}
+}
+
+func TestRun_prOptions(t *testing.T) {
+	dir := setupBranch(t)
+
+	template := filepath.Join(dir, "template.md")
+	writeFile(t, template, "## Checklist\n")
+	_, errOut, status, prompt, _ := runPR(t, `{"title": "t", "body": "b"}`, nil, "-pr-template", template, "-base", "HEAD~1", "-language", "Japanese")
+	if status != ExitCodeOK {
+		t.Fatalf("ExitStatus=%d, want %d: %s", status, ExitCodeOK, errOut)
+	}
+	if !strings.Contains(prompt, "## Checklist") || !strings.Contains(prompt, "in Japanese") {
+		t.Errorf("expected the template and the language in the prompt, got %q", prompt)
+	}
+
+	// A response that is not JSON is read as Markdown.
+	out, _, _, _, _ := runPR(t, "# Add the entry point\n\nIt adds main.go.", nil)
+	if out != "# Add the entry point\n\nIt adds main.go.\n" {
+		t.Errorf("unexpected output %q", out)
+	}
+
+	// The input piped in is used as is.
+	stdin := "my own summary of the changes"
+	_, errOut, status, _, input := runPR(t, `{"title": "t", "body": "b"}`, &stdin)
+	if status != ExitCodeOK || input != stdin {
+		t.Errorf("expected the piped input, got %q (ExitStatus=%d: %s)", input, status, errOut)
+	}
+
+	git(t, "switch", "-q", "main")
+	if _, errOut, status, _, _ := runPR(t, "unused", nil); status != ExitCodeFail || !strings.Contains(errOut, "no commits between main and HEAD") {
+		t.Errorf("expected an error without commits, got %d: %q", status, errOut)
+	}
+}
+
+func TestRun_prInvalidBase(t *testing.T) {
+	setupBranch(t)
+
+	for _, base := range []string{"missing", "--output=leak"} {
+		_, errOut, status, _, _ := runPR(t, `{"title": "t", "body": "b"}`, nil, "-base", base)
+		if status != ExitCodeFail {
+			t.Errorf("%s: ExitStatus=%d, want %d", base, status, ExitCodeFail)
+		}
+		if want := "is not a commit"; !strings.Contains(errOut, want) {
+			t.Errorf("%s: expected %q in the error, got %q", base, want, errOut)
+		}
+	}
+	if leaked, _ := filepath.Glob("leak*"); len(leaked) != 0 {
+		t.Errorf("expected the base not to be taken for an option of git, got %v", leaked)
+	}
+}
+
+func TestRun_prErrors(t *testing.T) {
+	input := setupConfig(t, "", "")
+
+	tests := [][]string{
+		{"-review", "-json", "-file", input},
+		{"-commit", "-pr-template", input, "-file", input},
+		{"-pr", "-stream", "-file", input},
+		{"-pr", "-base", "main", "-file", input},
+	}
+	for _, args := range tests {
+		if _, _, status := runBento(t, &MockTranslator{}, env{terminal: true}, args...); status != ExitCodeFail {
+			t.Errorf("%v: ExitStatus=%d, want %d", args, status, ExitCodeFail)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/catatsuy/bento/internal/placeholder"
+	"github.com/catatsuy/bento/internal/subtitle"
+)
+
+// subtitleInstruction is added to the system prompt in subtitle mode.
+const subtitleInstruction = "The values are consecutive subtitle cues. Translate each cue on its own so that it fits its timing, using the neighboring cues as context, and keep its line breaks."
+
+// isSubtitleFile reports whether path is an SRT or WebVTT file.
+func isSubtitleFile(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".srt", ".vtt":
+		return true
+	}
+	return false
+}
+
+// subtitleRequest translates an SRT or WebVTT file read from the input stream.
+// Only the text of the cues is sent, in batches keyed by the cue position, so the
+// translations are put back into their cues and the numbers and timestamps are kept.
+func (c *CLI) subtitleRequest(ctx context.Context, systemPrompt, prompt, useModel string, opts multiOptions) error {
+	b, err := io.ReadAll(c.inputStream)
+	if err != nil {
+		return fmt.Errorf("error reading input: %w", err)
+	}
+	f, err := subtitle.Parse(string(b))
+	if err != nil {
+		return err
+	}
+
+	cues := f.Cues()
+	protected := make([][]string, len(cues))
+	var items []batchItem
+	for i, cue := range cues {
+		if strings.TrimSpace(cue.Text) == "" {
+			continue
+		}
+		var text string
+		text, protected[i] = placeholder.Protect(cue.Text, subtitle.Tags)
+		items = append(items, batchItem{id: strconv.Itoa(i), text: text})
+	}
+
+	if systemPrompt != "" {
+		systemPrompt += "\n\n"
+	}
+	systemPrompt += subtitleInstruction
+
+	translations, err := c.translateBatches(ctx, systemPrompt, prompt, useModel, opts, items)
+	if err != nil {
+		return err
+	}
+	for _, item := range items {
+		i, _ := strconv.Atoi(item.id)
+		cue := cues[i]
+		t, ok := translations[item.id]
+		if !ok || strings.TrimSpace(t) == "" {
+			fmt.Fprintf(c.errStream, "Warning: The cue %d (%s) was not translated.\n", i+1, cue.Timing)
+			continue
+		}
+		restored, err := placeholder.Restore(t, protected[i])
+		if err != nil {
+			fmt.Fprintf(c.errStream, "Warning: The cue %d (%s) was not translated because the response did not keep its tags: %v\n", i+1, cue.Timing, err)
+			continue
+		}
+		cue.Translation = restored
+	}
+
+	_, err = io.WriteString(c.outStream, f.String())
+	return err
+}
@@ -0,0 +1,117 @@
+package cli_test
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/catatsuy/bento/internal/cli"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRun_subtitleSRT(t *testing.T) {
+	var texts []string
+	// Subtitle mode is the default for .srt files.
+	out, _ := mustRunBento(t, catalogTranslator(t, &texts), env{terminal: true}, "-translate", "-language", "ja", "-file", "testdata/subtitle.srt")
+
+	expected := "1\r\n00:00:01,000 --> 00:00:03,500\r\n<i>HELLO, WORLD!</i>\r\n\r\n" +
+		"2\r\n00:00:04,000 --> 00:00:06,000\r\n{\\an8}WHERE ARE YOU GOING?\r\nTO THE STATION.\r\n\r\n" +
+		"3\r\n00:00:07,000 --> 00:00:08,000\r\nGOODBYE.\r\n"
+	if diff := cmp.Diff(expected, out); diff != "" {
+		t.Errorf("subtitles mismatch (-expected +actual):\n%s", diff)
+	}
+
+	// Only the text of the cues is sent, without the tags.
+	expectedTexts := []string{"⟦0⟧Hello, world!⟦1⟧", "⟦0⟧Where are you going?\nTo the station.", "Goodbye."}
+	if diff := cmp.Diff(expectedTexts, texts, sortStrings); diff != "" {
+		t.Errorf("translated texts mismatch (-expected +actual):\n%s", diff)
+	}
+}
+
+func TestRun_subtitleVTTBatches(t *testing.T) {
+	m := &mockModel{respond: func(r request) string {
+		// The keys are kept but the order of the response differs.
+		if strings.Contains(r.input, `"2"`) {
+			return `{"2": "WHERE ARE YOU GOING?", "1": "⟦0⟧HELLO, WORLD!"}`
+		}
+		return `{"1": "TO THE STATION."}`
+	}}
+	out, _ := mustRunBento(t, m.translator(), env{stdin: vttInput}, "-translate", "-subtitle", "-limit", "40")
+
+	calls := m.calls()
+	if len(calls) != 2 {
+		t.Errorf("expected 2 requests, got %d: %q", len(calls), calls)
+	}
+	for _, r := range calls {
+		if !strings.Contains(r.system, "subtitle cues") {
+			t.Errorf("expected the system prompt to describe the cues, got %q", r.system)
+		}
+	}
+
+	expected := `WEBVTT
+
+00:00:01.000 --> 00:00:03.500
+<v Alice>HELLO, WORLD!
+
+00:00:04.000 --> 00:00:05.000
+WHERE ARE YOU GOING?
+
+00:00:05.000 --> 00:00:06.000
+TO THE STATION.
+`
+	if diff := cmp.Diff(expected, out); diff != "" {
+		t.Errorf("subtitles mismatch (-expected +actual):\n%s", diff)
+	}
+}
+
+const vttInput = `WEBVTT
+
+00:00:01.000 --> 00:00:03.500
+<v Alice>Hello, world!
+
+00:00:04.000 --> 00:00:05.000
+Where are you going?
+
+00:00:05.000 --> 00:00:06.000
+To the station.
+`
+
+func TestRun_subtitleLostTag(t *testing.T) {
+	m := newMockModel(`{"1": "HELLO, WORLD!", "2": "WHERE ARE YOU GOING?"}`)
+	out, errOut := mustRunBento(t, m.translator(), env{stdin: vttInput}, "-translate", "-subtitle")
+
+	// The first cue lost its voice tag and the third one is missing, so they are not translated.
+	for _, s := range []string{"<v Alice>Hello, world!", "WHERE ARE YOU GOING?", "To the station."} {
+		if !strings.Contains(out, s) {
+			t.Errorf("expected %q in the output, got:\n%s", s, out)
+		}
+	}
+	for _, s := range []string{"The cue 1 (00:00:01.000 --> 00:00:03.500)", "The cue 3 (00:00:05.000 --> 00:00:06.000)"} {
+		if !strings.Contains(errOut, "Warning: "+s) {
+			t.Errorf("expected a warning for %s, got %q", s, errOut)
+		}
+	}
+}
+
+func TestRun_subtitleInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"single mode", []string{"-subtitle", "-single", "-prompt", "Translate"}, "only be used in multi mode"},
+		{"markdown", []string{"-translate", "-subtitle", "-markdown"}, "cannot be specified simultaneously"},
+		{"no cues", []string{"-translate", "-subtitle"}, "no subtitle cues found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &mockModel{respond: func(r request) string { return r.input }}
+			_, errOut, status := runBento(t, m.translator(), env{stdin: "Hello, world!\n"}, tt.args...)
+			if status != ExitCodeFail {
+				t.Errorf("ExitStatus=%d, want %d", status, ExitCodeFail)
+			}
+			if !strings.Contains(errOut, tt.want) {
+				t.Errorf("expected %q in the error, got %q", tt.want, errOut)
+			}
+		})
+	}
+}
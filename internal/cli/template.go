@@ -0,0 +1,194 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"text/template"
+	"text/template/parse"
+)
+
+// PromptData is the data available to prompt templates.
+type PromptData struct {
+	// Input is the input text, or the current chunk in multi mode.
+	// It is only available in the prompt, not in the system prompt.
+	Input    string
+	Language string
+	FileName string
+	Branch   string
+	// Vars are the variables given with -var key=value.
+	Vars map[string]string
+}
+
+// templateTranslator renders the system prompt and the prompt as text/template
+// templates before passing them to the wrapped Translator.
+// If the prompt refers to .Input, the rendered prompt is sent as the whole message;
+// otherwise the input is appended to the rendered prompt as before.
+type templateTranslator struct {
+	Translator
+
+	data PromptData
+
+	// branch is called lazily because it runs git.
+	branch     func() string
+	branchOnce sync.Once
+	branchName string
+}
+
+// newTemplateTranslator wraps tr to render prompts with data.
+// branch is called only if a template refers to .Branch.
+func newTemplateTranslator(tr Translator, data PromptData, branch func() string) *templateTranslator {
+	return &templateTranslator{Translator: tr, data: data, branch: branch}
+}
+
+func (tt *templateTranslator) request(ctx context.Context, systemPrompt, prompt, input, model string) (string, error) {
+	systemPrompt, prompt, input, err := tt.render(systemPrompt, prompt, input)
+	if err != nil {
+		return "", err
+	}
+	return tt.Translator.request(ctx, systemPrompt, prompt, input, model)
+}
+
+func (tt *templateTranslator) requestStream(ctx context.Context, systemPrompt, prompt, input, model string, w io.Writer) error {
+	systemPrompt, prompt, input, err := tt.render(systemPrompt, prompt, input)
+	if err != nil {
+		return err
+	}
+	_, err = streamOrWrite(ctx, tt.Translator, systemPrompt, prompt, input, model, w)
+	return err
+}
+
+func (tt *templateTranslator) requestCandidates(ctx context.Context, systemPrompt, prompt, input, model string, n int) ([]string, error) {
+	systemPrompt, prompt, input, err := tt.render(systemPrompt, prompt, input)
+	if err != nil {
+		return nil, err
+	}
+	return requestCandidates(ctx, tt.Translator, systemPrompt, prompt, input, model, n)
+}
+
+func (tt *templateTranslator) usage() Usage {
+	return usageOf(tt.Translator)
+}
+
+// usesBranch reports whether the system prompt or the prompt template refers to .Branch.
+// Templates that cannot be parsed are reported when they are rendered.
+func usesBranch(systemPrompt, prompt string) bool {
+	for _, text := range []string{systemPrompt, prompt} {
+		tmpl, err := template.New("").Parse(text)
+		if err == nil && usesField(tmpl.Root, "Branch") {
+			return true
+		}
+	}
+	return false
+}
+
+// escapeTemplate escapes the actions in s, so that text inserted in a prompt, such as a file, is sent as it is.
+func escapeTemplate(s string) string {
+	return strings.ReplaceAll(s, "{{", `{{"{{"}}`)
+}
+
+// render renders the templates and returns the system prompt, prompt and input to send.
+func (tt *templateTranslator) render(systemPrompt, prompt, input string) (string, string, string, error) {
+	sysTmpl, err := template.New("system").Option("missingkey=error").Parse(systemPrompt)
+	if err != nil {
+		return "", "", "", fmt.Errorf("failed to parse the system prompt template: %w", err)
+	}
+	promptTmpl, err := template.New("prompt").Option("missingkey=error").Parse(prompt)
+	if err != nil {
+		return "", "", "", fmt.Errorf("failed to parse the prompt template: %w", err)
+	}
+
+	data := tt.data
+	if usesField(sysTmpl.Root, "Branch") || usesField(promptTmpl.Root, "Branch") {
+		tt.branchOnce.Do(func() {
+			if tt.branch != nil {
+				tt.branchName = tt.branch()
+			}
+		})
+		data.Branch = tt.branchName
+	}
+
+	var b strings.Builder
+	if err := sysTmpl.Execute(&b, data); err != nil {
+		return "", "", "", fmt.Errorf("failed to render the system prompt template: %w", err)
+	}
+	renderedSystem := b.String()
+
+	usesInput := usesField(promptTmpl.Root, "Input")
+	if usesInput {
+		data.Input = input
+	}
+	b.Reset()
+	if err := promptTmpl.Execute(&b, data); err != nil {
+		return "", "", "", fmt.Errorf("failed to render the prompt template: %w", err)
+	}
+
+	if usesInput {
+		// The input is already placed in the prompt, so the whole message is sent as the input.
+		return renderedSystem, "", b.String(), nil
+	}
+	return renderedSystem, b.String(), input, nil
+}
+
+// usesField reports whether the template tree refers to the top-level field name, e.g. {{.Input}}.
+func usesField(node parse.Node, name string) bool {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return false
+		}
+		for _, child := range n.Nodes {
+			if usesField(child, name) {
+				return true
+			}
+		}
+	case *parse.ActionNode:
+		return usesField(n.Pipe, name)
+	case *parse.PipeNode:
+		if n == nil {
+			return false
+		}
+		for _, cmd := range n.Cmds {
+			if usesField(cmd, name) {
+				return true
+			}
+		}
+	case *parse.CommandNode:
+		for _, arg := range n.Args {
+			if usesField(arg, name) {
+				return true
+			}
+		}
+	case *parse.FieldNode:
+		return len(n.Ident) > 0 && n.Ident[0] == name
+	case *parse.ChainNode:
+		return usesField(n.Node, name)
+	case *parse.IfNode:
+		return usesBranchField(&n.BranchNode, name)
+	case *parse.RangeNode:
+		return usesBranchField(&n.BranchNode, name)
+	case *parse.WithNode:
+		return usesBranchField(&n.BranchNode, name)
+	case *parse.TemplateNode:
+		return usesField(n.Pipe, name)
+	}
+	return false
+}
+
+func usesBranchField(n *parse.BranchNode, name string) bool {
+	return usesField(n.Pipe, name) || usesField(n.List, name) || usesField(n.ElseList, name)
+}
+
+// parseVar parses a -var flag value of the form key=value.
+func parseVar(vars map[string]string) func(string) error {
+	return func(s string) error {
+		key, value, ok := strings.Cut(s, "=")
+		if !ok || key == "" {
+			return fmt.Errorf("invalid variable %q: use key=value", s)
+		}
+		vars[key] = value
+		return nil
+	}
+}
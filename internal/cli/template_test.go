@@ -0,0 +1,102 @@
+package cli_test
+
+import (
+	"os/exec"
+	"testing"
+
+	. "github.com/catatsuy/bento/internal/cli"
+)
+
+type templateCall struct {
+	system, prompt, text string
+}
+
+func runTemplate(t *testing.T, args ...string) templateCall {
+	t.Helper()
+
+	m := newMockModel("ok")
+	mustRunBento(t, m.translator(), env{terminal: true}, args...)
+	r := m.last()
+	return templateCall{r.system, r.prompt, r.input}
+}
+
+func TestRun_promptTemplateWithInput(t *testing.T) {
+	call := runTemplate(t, "-prompt", `Translate to {{.Language}} for {{.Vars.audience}}:\n{{.Input}}Thanks.`, "-language", "ja", "-var", "audience=engineers", "-file", "testdata/test.txt")
+
+	expected := templateCall{
+		prompt: "",
+		text:   "Translate to ja for engineers:\nThis is a test.\nAnother line without dot\nThanks.",
+	}
+	if call != expected {
+		t.Errorf("got %+v, want %+v", call, expected)
+	}
+}
+
+func TestRun_promptTemplateWithoutInput(t *testing.T) {
+	call := runTemplate(t, "-prompt", `Summarize {{.FileName}}:\n\n`, "-file", "testdata/test.txt")
+
+	expected := templateCall{
+		prompt: "Summarize testdata/test.txt:\n\n",
+		text:   "This is a test.\nAnother line without dot\n",
+	}
+	if call != expected {
+		t.Errorf("got %+v, want %+v", call, expected)
+	}
+}
+
+func TestRun_promptFiles(t *testing.T) {
+	call := runTemplate(t, "-prompt-file", "testdata/prompts/summarize.tmpl", "-system-file", "testdata/prompts/system.tmpl", "-language", "French", "-var", "audience=managers", "-file", "testdata/test.txt")
+
+	expected := templateCall{
+		system: "You are writing in French.\n",
+		prompt: "",
+		text:   "Summarize testdata/test.txt for managers:\n\nThis is a test.\nAnother line without dot\n\nEnd.\n",
+	}
+	if call != expected {
+		t.Errorf("got %+v, want %+v", call, expected)
+	}
+}
+
+func TestRun_promptTemplateBranch(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	input := setupConfig(t, "", "")
+	dir := t.TempDir()
+	t.Chdir(dir)
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"checkout", "-q", "-b", "feature/template"},
+	} {
+		if out, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v: %s", args, err, out)
+		}
+	}
+
+	call := runTemplate(t, "-prompt", `Branch {{.Branch}}:\n`, "-file", input)
+	if call.prompt != "Branch feature/template:\n" {
+		t.Errorf("prompt=%q, want %q", call.prompt, "Branch feature/template:\n")
+	}
+}
+
+func TestRun_promptTemplateErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{"prompt and prompt file", []string{"-prompt", "a", "-prompt-file", "testdata/prompts/summarize.tmpl"}},
+		{"invalid template", []string{"-prompt", "{{.Input"}},
+		{"missing variable", []string{"-prompt", "{{.Vars.missing}}"}},
+		{"invalid var", []string{"-prompt", "a", "-var", "novalue"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := append(tt.args, "-file", "testdata/test.txt")
+			if _, _, status := runBento(t, newMockModel("ok").translator(), env{terminal: true}, args...); status != ExitCodeFail {
+				t.Errorf("ExitStatus=%d, want %d", status, ExitCodeFail)
+			}
+		})
+	}
+}
@@ -0,0 +1,218 @@
+package cli_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/catatsuy/bento/internal/anthropic"
+	. "github.com/catatsuy/bento/internal/cli"
+	"github.com/catatsuy/bento/internal/gemini"
+	"github.com/catatsuy/bento/internal/openai"
+)
+
+// conformanceBackend describes how to talk to a fake server of a backend.
+type conformanceBackend struct {
+	name string
+	// newTranslator creates a Translator sending requests to url.
+	newTranslator func(t *testing.T, url string) (Translator, error)
+	// parse extracts the system prompt and the user message from a request body.
+	parse func(t *testing.T, r *http.Request) (system, user string)
+	// respond writes a response containing text, or no output if text is empty.
+	respond func(w http.ResponseWriter, text string)
+}
+
+func chatCompletionsParse(t *testing.T, r *http.Request) (string, string) {
+	payload := &gemini.Payload{}
+	if err := json.NewDecoder(r.Body).Decode(payload); err != nil {
+		t.Fatal(err)
+	}
+	var system, user string
+	for _, m := range payload.Messages {
+		switch m.Role {
+		case "system":
+			system = m.Content
+		case "user":
+			user = m.Content
+		}
+	}
+	return system, user
+}
+
+func chatCompletionsRespond(w http.ResponseWriter, text string) {
+	if text == "" {
+		fmt.Fprint(w, `{"choices":[]}`)
+		return
+	}
+	fmt.Fprintf(w, `{"choices":[{"index":0,"message":{"role":"assistant","content":%q},"finish_reason":"stop"}]}`, text)
+}
+
+var conformanceBackends = []conformanceBackend{
+	{
+		name: "openai",
+		newTranslator: func(t *testing.T, url string) (Translator, error) {
+			orig := openai.OpenAIAPIURL
+			openai.OpenAIAPIURL = url
+			t.Cleanup(func() { openai.OpenAIAPIURL = orig })
+			return NewOpenAITranslator("token", nil)
+		},
+		parse: func(t *testing.T, r *http.Request) (string, string) {
+			payload := &openai.Payload{}
+			if err := json.NewDecoder(r.Body).Decode(payload); err != nil {
+				t.Fatal(err)
+			}
+			return payload.Instructions, payload.Input
+		},
+		respond: func(w http.ResponseWriter, text string) {
+			if text == "" {
+				fmt.Fprint(w, `{"id":"resp_1","output":[]}`)
+				return
+			}
+			fmt.Fprintf(w, `{"id":"resp_1","output":[{"type":"message","role":"assistant","content":[{"type":"output_text","text":%q}]}]}`, text)
+		},
+	},
+	{
+		name: "gemini",
+		newTranslator: func(t *testing.T, url string) (Translator, error) {
+			orig := gemini.GeminiAPIURL
+			gemini.GeminiAPIURL = url
+			t.Cleanup(func() { gemini.GeminiAPIURL = orig })
+			return NewGeminiTranslator("token", nil)
+		},
+		parse:   chatCompletionsParse,
+		respond: chatCompletionsRespond,
+	},
+	{
+		name: "openai-compatible",
+		newTranslator: func(t *testing.T, url string) (Translator, error) {
+			return NewOpenAICompatibleTranslator(url, "", nil)
+		},
+		parse:   chatCompletionsParse,
+		respond: chatCompletionsRespond,
+	},
+	{
+		name: "anthropic",
+		newTranslator: func(t *testing.T, url string) (Translator, error) {
+			orig := anthropic.AnthropicAPIURL
+			anthropic.AnthropicAPIURL = url
+			t.Cleanup(func() { anthropic.AnthropicAPIURL = orig })
+			return NewAnthropicTranslator("token", nil)
+		},
+		parse: func(t *testing.T, r *http.Request) (string, string) {
+			payload := &anthropic.Payload{}
+			if err := json.NewDecoder(r.Body).Decode(payload); err != nil {
+				t.Fatal(err)
+			}
+			var user string
+			for _, m := range payload.Messages {
+				if m.Role == "user" {
+					user = m.Content
+				}
+			}
+			return payload.System, user
+		},
+		respond: func(w http.ResponseWriter, text string) {
+			if text == "" {
+				fmt.Fprint(w, `{"id":"msg_1","type":"message","role":"assistant","content":[],"stop_reason":"end_turn"}`)
+				return
+			}
+			fmt.Fprintf(w, `{"id":"msg_1","type":"message","role":"assistant","content":[{"type":"text","text":%q}],"stop_reason":"end_turn"}`, text)
+		},
+	},
+}
+
+// TestTranslatorConformance runs the same scenarios against every backend
+// so that the backends do not drift apart.
+func TestTranslatorConformance(t *testing.T) {
+	for _, b := range conformanceBackends {
+		t.Run(b.name, func(t *testing.T) {
+			t.Run("system prompt", func(t *testing.T) {
+				var system, user string
+				server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					system, user = b.parse(t, r)
+					b.respond(w, "translated")
+				}))
+				defer server.Close()
+
+				tr, err := b.newTranslator(t, server.URL)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				out, err := Request(t.Context(), tr, "You are a translator.", "Translate: ", "hello", "model")
+				if err != nil {
+					t.Fatal(err)
+				}
+				if out != "translated" {
+					t.Errorf("output=%q, want %q", out, "translated")
+				}
+				if system != "You are a translator." {
+					t.Errorf("system prompt=%q, want %q", system, "You are a translator.")
+				}
+				if user != "Translate: hello" {
+					t.Errorf("user message=%q, want %q", user, "Translate: hello")
+				}
+			})
+
+			t.Run("no system prompt", func(t *testing.T) {
+				var system string
+				server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					system, _ = b.parse(t, r)
+					b.respond(w, "translated")
+				}))
+				defer server.Close()
+
+				tr, err := b.newTranslator(t, server.URL)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if _, err := Request(t.Context(), tr, "", "Translate: ", "hello", "model"); err != nil {
+					t.Fatal(err)
+				}
+				if system != "" {
+					t.Errorf("system prompt=%q, want empty", system)
+				}
+			})
+
+			t.Run("empty input", func(t *testing.T) {
+				called := false
+				server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					called = true
+					b.respond(w, "translated")
+				}))
+				defer server.Close()
+
+				tr, err := b.newTranslator(t, server.URL)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if _, err := Request(t.Context(), tr, "", "Translate: ", "", "model"); err == nil {
+					t.Error("expected error, got nil")
+				}
+				if called {
+					t.Error("expected no request to be sent for empty input")
+				}
+			})
+
+			t.Run("no output", func(t *testing.T) {
+				server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					b.respond(w, "")
+				}))
+				defer server.Close()
+
+				tr, err := b.newTranslator(t, server.URL)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if _, err := Request(t.Context(), tr, "", "Translate: ", "hello", "model"); err == nil {
+					t.Error("expected error, got nil")
+				}
+			})
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Usage is the number of tokens used by the requests of a run.
+type Usage struct {
+	Requests     int
+	InputTokens  int
+	OutputTokens int
+}
+
+// usageReporter is implemented by Translators that report the tokens used so far.
+type usageReporter interface {
+	usage() Usage
+}
+
+// usageOf returns the usage reported by tr, or no usage if tr does not report it.
+// The Translators wrapping another one report the usage of the wrapped one with it.
+func usageOf(tr Translator) Usage {
+	if ur, ok := tr.(usageReporter); ok {
+		return ur.usage()
+	}
+	return Usage{}
+}
+
+// usageMeter aggregates the usage of requests. It is embedded in the Translators.
+type usageMeter struct {
+	mu    sync.Mutex
+	total Usage
+}
+
+// add records the usage of a single request.
+func (m *usageMeter) add(inputTokens, outputTokens int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.total.Requests++
+	m.total.InputTokens += inputTokens
+	m.total.OutputTokens += outputTokens
+}
+
+// usage returns the aggregated usage.
+func (m *usageMeter) usage() Usage {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.total
+}
+
+// modelPrice is the price in USD per million tokens.
+type modelPrice struct {
+	input  float64
+	output float64
+}
+
+// modelPrices is the built-in price table used to estimate the cost of a run.
+// Model names with a date or version suffix match by the longest prefix.
+// The prices are estimates and may be out of date.
+var modelPrices = map[string]modelPrice{
+	"gpt-5":                 {1.25, 10},
+	"gpt-5-mini":            {0.25, 2},
+	"gpt-5-nano":            {0.05, 0.4},
+	"gpt-4.1":               {2, 8},
+	"gpt-4.1-mini":          {0.4, 1.6},
+	"gpt-4.1-nano":          {0.1, 0.4},
+	"gpt-4o":                {2.5, 10},
+	"gpt-4o-mini":           {0.15, 0.6},
+	"o3":                    {2, 8},
+	"o4-mini":               {1.1, 4.4},
+	"gemini-2.0-flash":      {0.1, 0.4},
+	"gemini-2.0-flash-lite": {0.075, 0.3},
+	"gemini-2.5-flash":      {0.3, 2.5},
+	"gemini-2.5-flash-lite": {0.1, 0.4},
+	"gemini-2.5-pro":        {1.25, 10},
+	"claude-haiku-4-5":      {1, 5},
+	"claude-sonnet-4-5":     {3, 15},
+	"claude-sonnet-4":       {3, 15},
+	"claude-opus-4-1":       {15, 75},
+	"claude-3-5-haiku":      {0.8, 4},
+}
+
+// lookupPrice returns the price of model, matching the longest known prefix.
+func lookupPrice(model string) (modelPrice, bool) {
+	if p, ok := modelPrices[model]; ok {
+		return p, true
+	}
+	var (
+		price   modelPrice
+		longest string
+	)
+	for name, p := range modelPrices {
+		if strings.HasPrefix(model, name+"-") && len(name) > len(longest) {
+			price, longest = p, name
+		}
+	}
+	return price, longest != ""
+}
+
+// estimateCost returns the estimated cost of u in USD.
+func estimateCost(model string, u Usage) (float64, bool) {
+	p, ok := lookupPrice(model)
+	if !ok {
+		return 0, false
+	}
+	return (float64(u.InputTokens)*p.input + float64(u.OutputTokens)*p.output) / 1_000_000, true
+}
+
+// printUsage writes the token usage and the estimated cost of the run to errStream.
+func (c *CLI) printUsage(model string) {
+	ur, ok := c.translator.(usageReporter)
+	if !ok {
+		return
+	}
+	u := ur.usage()
+	if u.Requests == 0 {
+		return
+	}
+
+	fmt.Fprintf(c.errStream, "Usage: %d requests, %d input tokens, %d output tokens, %d total tokens\n",
+		u.Requests, u.InputTokens, u.OutputTokens, u.InputTokens+u.OutputTokens)
+	if cost, ok := estimateCost(model, u); ok {
+		fmt.Fprintf(c.errStream, "Estimated cost: $%.6f (%s)\n", cost, model)
+	} else {
+		fmt.Fprintf(c.errStream, "Estimated cost: unknown (no price for %s)\n", model)
+	}
+}
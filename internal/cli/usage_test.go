@@ -0,0 +1,48 @@
+package cli_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRun_usage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"choices":[{"index":0,"message":{"role":"assistant","content":"translated"}}],"usage":{"prompt_tokens":100,"completion_tokens":20,"total_tokens":120}}`)
+	}))
+	defer server.Close()
+
+	t.Setenv("BENTO_BASE_URL", server.URL)
+
+	// testdata/test.txt has two lines, so a small limit splits it into two requests.
+	_, errOut := mustRunBento(t, nil, env{terminal: true}, "-backend", "openai-compatible", "-model", "gpt-5-nano-2025-08-07", "-translate", "-limit", "25", "-usage", "-file", "testdata/test.txt")
+
+	expected := "Usage: 2 requests, 200 input tokens, 40 output tokens, 240 total tokens\n"
+	if !strings.Contains(errOut, expected) {
+		t.Errorf("Output=%q, want %q", errOut, expected)
+	}
+
+	// gpt-5-nano: $0.05 per million input tokens and $0.40 per million output tokens.
+	expected = "Estimated cost: $0.000026 (gpt-5-nano-2025-08-07)\n"
+	if !strings.Contains(errOut, expected) {
+		t.Errorf("Output=%q, want %q", errOut, expected)
+	}
+}
+
+func TestRun_usageUnknownModel(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"choices":[{"index":0,"message":{"role":"assistant","content":"ok"}}],"usage":{"prompt_tokens":1,"completion_tokens":1,"total_tokens":2}}`)
+	}))
+	defer server.Close()
+
+	t.Setenv("BENTO_BASE_URL", server.URL)
+
+	_, errOut := mustRunBento(t, nil, env{terminal: true}, "-backend", "openai-compatible", "-model", "llama3", "-commit", "-usage", "-file", "testdata/test.txt")
+
+	expected := "Estimated cost: unknown (no price for llama3)\n"
+	if !strings.Contains(errOut, expected) {
+		t.Errorf("Output=%q, want %q", errOut, expected)
+	}
+}
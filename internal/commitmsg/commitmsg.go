@@ -0,0 +1,129 @@
+// Package commitmsg formats suggested commit messages and checks them against
+// the rules of a commit style, such as Conventional Commits.
+package commitmsg
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// Style is a style of commit messages.
+type Style string
+
+// Styles of commit messages.
+const (
+	// Subject is a message of a subject line only. It is the style when none is chosen.
+	Subject Style = ""
+	// Plain is a subject line and an optional body.
+	Plain Style = "plain"
+	// Conventional follows Conventional Commits: "type(scope): description".
+	Conventional Style = "conventional"
+	// Gitmoji starts the subject line with a gitmoji.
+	Gitmoji Style = "gitmoji"
+	// Custom is written with a prompt of the user and checked like Plain.
+	Custom Style = "custom"
+)
+
+const (
+	// MaxSubjectLength is the maximum number of characters of the subject line.
+	MaxSubjectLength = 72
+	// BodyWidth is the number of characters the lines of the body are wrapped at.
+	BodyWidth = 72
+)
+
+var (
+	// conventionalSubject matches "type(scope)!: description" with an optional scope and "!".
+	conventionalSubject = regexp.MustCompile(`^[a-z]+(\([\w$.,/ -]+\))?!?: \S`)
+	// gitmojiSubject matches an emoji, or its :shortcode:, followed by a space.
+	gitmojiSubject = regexp.MustCompile(`^(:[a-z0-9_+-]+:|\p{So}[\x{FE0F}\x{200D}\p{So}]*) +\S`)
+	// listItem matches the marker of a list item in the body.
+	listItem = regexp.MustCompile(`^\s*([-*+]|\d+[.)])\s+`)
+)
+
+// ParseStyle returns the style named s.
+func ParseStyle(s string) (Style, error) {
+	switch style := Style(s); style {
+	case Subject, Plain, Conventional, Gitmoji, Custom:
+		return style, nil
+	}
+	return "", fmt.Errorf("unknown commit style %q: use plain, conventional, gitmoji or custom", s)
+}
+
+// Validate returns the first rule of style that msg breaks, or nil.
+func Validate(msg string, style Style) error {
+	if strings.TrimSpace(msg) == "" {
+		return errors.New("the message is empty")
+	}
+	subject, body, hasBody := strings.Cut(msg, "\n")
+	if strings.TrimSpace(subject) == "" {
+		return errors.New("the first line is empty instead of a subject line")
+	}
+	if n := utf8.RuneCountInString(subject); n > MaxSubjectLength {
+		return fmt.Errorf("the subject line has %d characters, more than %d", n, MaxSubjectLength)
+	}
+
+	switch style {
+	case Subject:
+		if hasBody {
+			return errors.New("the message has more than one line, but it must be a single subject line")
+		}
+	case Conventional:
+		if !conventionalSubject.MatchString(subject) {
+			return fmt.Errorf(`the subject line %q is not in the form "type(scope): description" with a lowercase type and an optional scope`, subject)
+		}
+	case Gitmoji:
+		if !gitmojiSubject.MatchString(subject) {
+			return fmt.Errorf("the subject line %q does not start with a gitmoji followed by a space", subject)
+		}
+	}
+
+	if hasBody {
+		if line, _, _ := strings.Cut(body, "\n"); strings.TrimSpace(line) != "" {
+			return errors.New("the subject line is not followed by a blank line")
+		}
+	}
+	return nil
+}
+
+// Format trims msg and the white space at the end of its lines, and wraps the lines
+// of the body at BodyWidth. The subject line is not wrapped.
+func Format(msg string) string {
+	lines := strings.Split(strings.TrimSpace(msg), "\n")
+	formatted := []string{strings.TrimRight(lines[0], " \t\r")}
+	for _, line := range lines[1:] {
+		formatted = append(formatted, wrap(strings.TrimRight(line, " \t\r"), BodyWidth)...)
+	}
+	return strings.Join(formatted, "\n")
+}
+
+// wrap breaks line at spaces into lines of at most width characters. The lines after
+// the first are indented like the text of a list item. Words longer than width are not broken.
+func wrap(line string, width int) []string {
+	if utf8.RuneCountInString(line) <= width {
+		return []string{line}
+	}
+
+	prefix := listItem.FindString(line)
+	if prefix == "" {
+		prefix = line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+	}
+	indent := strings.Repeat(" ", utf8.RuneCountInString(prefix))
+
+	var lines []string
+	current, empty := prefix, true
+	for word := range strings.FieldsSeq(line[len(prefix):]) {
+		if !empty && utf8.RuneCountInString(current)+1+utf8.RuneCountInString(word) > width {
+			lines = append(lines, current)
+			current, empty = indent, true
+		}
+		if !empty {
+			current += " "
+		}
+		current += word
+		empty = false
+	}
+	return append(lines, current)
+}
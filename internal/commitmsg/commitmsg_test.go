@@ -0,0 +1,82 @@
+package commitmsg_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	. "github.com/catatsuy/bento/internal/commitmsg"
+)
+
+func TestValidate(t *testing.T) {
+	long := strings.Repeat("a", MaxSubjectLength+1)
+	tests := []struct {
+		name    string
+		msg     string
+		style   Style
+		wantErr bool
+	}{
+		{"subject", "Fix typo in README", Subject, false},
+		{"subject with body", "Fix typo\n\nThe word was misspelled.", Subject, true},
+		{"empty", " \n", Plain, true},
+		{"long subject", long, Plain, true},
+		{"subject of 72 characters", strings.Repeat("あ", MaxSubjectLength), Plain, false},
+		{"plain with body", "Fix typo\n\nThe word was misspelled.", Plain, false},
+		{"no blank line", "Fix typo\nThe word was misspelled.", Plain, true},
+		{"no subject", "\nFix typo", Plain, true},
+		{"conventional", "feat(cli): add -n", Conventional, false},
+		{"conventional without scope", "fix: handle empty diffs\n\nbento failed when nothing was staged.", Conventional, false},
+		{"conventional breaking", "refactor(config)!: rename cache_dir", Conventional, false},
+		{"conventional scopes", "docs(readme, cli): describe -n", Conventional, false},
+		{"conventional uppercase type", "Feat: add -n", Conventional, true},
+		{"conventional no space", "feat:add -n", Conventional, true},
+		{"conventional no type", "Add -n", Conventional, true},
+		{"conventional empty scope", "feat(): add -n", Conventional, true},
+		{"gitmoji", "✨ Add -n", Gitmoji, false},
+		{"gitmoji with variation selector", "♻️ Refactor the picker", Gitmoji, false},
+		{"gitmoji shortcode", ":bug: Fix the picker", Gitmoji, false},
+		{"gitmoji missing", "Add -n", Gitmoji, true},
+		{"gitmoji no space", "✨Add -n", Gitmoji, true},
+		{"custom", "Anything goes\n\nas long as the subject is short.", Custom, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(tt.msg, tt.style)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate(%q, %q) = %v, want error: %v", tt.msg, tt.style, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFormat(t *testing.T) {
+	msg := "  feat(cli): add -n to choose from several suggestions  \r\n" +
+		"\r\n" +
+		"Branch names and commit messages are often not quite right on the first try, so bento can now request several of them at once.\n" +
+		"\n" +
+		"- The picker lets the user move between the suggestions with the arrow keys and choose one.\n" +
+		"- https://example.com/a/very/long/url/that/cannot/be/broken/at/all/because/it/has/no/spaces\n"
+	want := "feat(cli): add -n to choose from several suggestions\n" +
+		"\n" +
+		"Branch names and commit messages are often not quite right on the first\n" +
+		"try, so bento can now request several of them at once.\n" +
+		"\n" +
+		"- The picker lets the user move between the suggestions with the arrow\n" +
+		"  keys and choose one.\n" +
+		"- https://example.com/a/very/long/url/that/cannot/be/broken/at/all/because/it/has/no/spaces"
+	if diff := cmp.Diff(want, Format(msg)); diff != "" {
+		t.Errorf("Format mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestParseStyle(t *testing.T) {
+	for _, s := range []string{"", "plain", "conventional", "gitmoji", "custom"} {
+		if _, err := ParseStyle(s); err != nil {
+			t.Errorf("ParseStyle(%q): unexpected error %v", s, err)
+		}
+	}
+	if _, err := ParseStyle("angular"); err == nil {
+		t.Error("expected an error for an unknown style")
+	}
+}
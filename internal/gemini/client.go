@@ -1,31 +1,40 @@
 package gemini
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
-	"time"
+
+	"github.com/catatsuy/bento/internal/apiclient"
+	"github.com/catatsuy/bento/internal/sse"
 )
 
 // GeminiAPIURL is the correct API endpoint for Gemini.
 var GeminiAPIURL = "https://generativelanguage.googleapis.com/v1beta/openai/chat/completions"
 
 // Client handles requests to the Gemini API.
+// Its Provider is "gemini" or "openai-compatible".
 type Client struct {
-	URL        *url.URL
-	HTTPClient *http.Client
-	APIKey     string
+	apiclient.Client
+	APIKey string
 }
 
 // Payload is the request body for the Gemini API.
 // Note the use of "messages" to match the API specification.
 type Payload struct {
-	Model    string    `json:"model"`
-	Messages []Message `json:"messages"`
+	Model         string         `json:"model"`
+	Messages      []Message      `json:"messages"`
+	N             int            `json:"n,omitempty"`
+	Stream        bool           `json:"stream,omitempty"`
+	StreamOptions *StreamOptions `json:"stream_options,omitempty"`
+}
+
+// StreamOptions are the options for a streaming request.
+type StreamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
 }
 
 // Message represents a chat message.
@@ -41,6 +50,14 @@ type Response struct {
 	Created int64    `json:"created"`
 	Model   string   `json:"model"`
 	Choices []Choice `json:"choices"`
+	Usage   Usage    `json:"usage"`
+}
+
+// Usage reports the number of tokens used by the request.
+type Usage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
 }
 
 // Choice represents an answer choice in the response.
@@ -63,20 +80,35 @@ func NewClient(urlStr, apiKey string) (*Client, error) {
 		return nil, fmt.Errorf("failed to parse url %s: %w", urlStr, err)
 	}
 	return &Client{
-		URL:        parsedURL,
-		HTTPClient: &http.Client{Timeout: 30 * time.Second},
-		APIKey:     apiKey,
+		Client: apiclient.New(parsedURL, "gemini"),
+		APIKey: apiKey,
 	}, nil
 }
 
-// newRequest creates a new HTTP request.
-func (c *Client) newRequest(ctx context.Context, method string, body io.Reader) (*http.Request, error) {
-	req, err := http.NewRequest(method, c.URL.String(), body)
+// NewCompatibleClient creates a client for any server implementing the
+// OpenAI chat/completions API, such as Ollama, llama.cpp server or vLLM.
+// Unlike NewClient, the API key is optional.
+func NewCompatibleClient(urlStr, apiKey string) (*Client, error) {
+	if urlStr == "" {
+		return nil, fmt.Errorf("compatible client: missing url")
+	}
+	parsedURL, err := url.ParseRequestURI(urlStr)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("failed to parse url %s: %w", urlStr, err)
 	}
-	req = req.WithContext(ctx)
-	return req, nil
+	return &Client{
+		Client: apiclient.New(parsedURL, "openai-compatible"),
+		APIKey: apiKey,
+	}, nil
+}
+
+// header returns the headers authenticating a request. The API key is optional for compatible servers.
+func (c *Client) header() http.Header {
+	h := http.Header{}
+	if c.APIKey != "" {
+		h.Set("Authorization", "Bearer "+c.APIKey)
+	}
+	return h
 }
 
 // Chat sends a request to the Gemini API and returns the response.
@@ -85,38 +117,94 @@ func (c *Client) Chat(ctx context.Context, param *Payload) (*Response, error) {
 		return nil, fmt.Errorf("missing message content")
 	}
 
-	b, err := json.Marshal(param)
+	res, err := c.Post(ctx, c.header(), param)
 	if err != nil {
-		return nil, fmt.Errorf("marshal error: %w", err)
+		return nil, err
 	}
+	defer res.Body.Close()
 
-	req, err := c.newRequest(ctx, http.MethodPost, bytes.NewBuffer(b))
+	response := &Response{}
+	err = json.NewDecoder(res.Body).Decode(response)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("decode error: %w", err)
 	}
 
-	req.Header.Set("Authorization", "Bearer "+c.APIKey)
-	req.Header.Set("Content-Type", "application/json")
+	return response, nil
+}
+
+// StreamChunk is a chunk of a streamed chat/completions response.
+type StreamChunk struct {
+	ID      string        `json:"id"`
+	Choices []StreamDelta `json:"choices"`
+	Usage   *Usage        `json:"usage"`
+}
 
-	res, err := c.HTTPClient.Do(req)
+// StreamDelta is the delta of a choice in a StreamChunk.
+type StreamDelta struct {
+	Index        int     `json:"index"`
+	Delta        Message `json:"delta"`
+	FinishReason string  `json:"finish_reason"`
+}
+
+// ChatStream sends a streaming request and calls fn with each text delta as it arrives.
+// It returns the usage reported at the end of the stream, or nil if none was reported.
+func (c *Client) ChatStream(ctx context.Context, param *Payload, fn func(delta string) error) (*Usage, error) {
+	if len(param.Messages) == 0 || param.Messages[0].Content == "" {
+		return nil, fmt.Errorf("missing message content")
+	}
+
+	p := *param
+	p.Stream = true
+	p.StreamOptions = &StreamOptions{IncludeUsage: true}
+
+	res, err := c.PostStream(ctx, c.header(), &p)
 	if err != nil {
 		return nil, err
 	}
 	defer res.Body.Close()
 
-	if res.StatusCode != http.StatusOK {
-		bodyBytes, err := io.ReadAll(res.Body)
+	var (
+		usage    *Usage
+		finished bool
+	)
+	reader := sse.NewReader(res.Body)
+	for {
+		ev, err := reader.Next()
+		if err == io.EOF {
+			// The response ends with [DONE]. Some compatible servers omit it, but they still
+			// send the finish reason of the choice, which the truncated outputs lack.
+			if !finished {
+				return nil, sse.ErrIncomplete
+			}
+			return usage, nil
+		}
 		if err != nil {
-			return nil, fmt.Errorf("read body error: %w", err)
+			return nil, fmt.Errorf("read stream error: %w", err)
 		}
-		return nil, fmt.Errorf("status code: %d; body: %s", res.StatusCode, bodyBytes)
-	}
 
-	response := &Response{}
-	err = json.NewDecoder(res.Body).Decode(response)
-	if err != nil {
-		return nil, fmt.Errorf("decode error: %w", err)
-	}
+		if ev.Data == "[DONE]" {
+			return usage, nil
+		}
 
-	return response, nil
+		chunk := &StreamChunk{}
+		if err := json.Unmarshal([]byte(ev.Data), chunk); err != nil {
+			return nil, fmt.Errorf("decode stream error: %w", err)
+		}
+
+		if chunk.Usage != nil {
+			usage = chunk.Usage
+		}
+
+		for _, choice := range chunk.Choices {
+			if choice.Index == 0 && choice.FinishReason != "" {
+				finished = true
+			}
+			if choice.Index != 0 || choice.Delta.Content == "" {
+				continue
+			}
+			if err := fn(choice.Delta.Content); err != nil {
+				return nil, err
+			}
+		}
+	}
 }
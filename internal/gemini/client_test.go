@@ -3,14 +3,20 @@ package gemini_test
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/catatsuy/bento/internal/apierror"
 	. "github.com/catatsuy/bento/internal/gemini"
+	"github.com/catatsuy/bento/internal/retry"
+	"github.com/catatsuy/bento/internal/sse"
 	"github.com/google/go-cmp/cmp"
 )
 
@@ -86,6 +92,11 @@ func TestChat_Success(t *testing.T) {
 				FinishReason: "completed",
 			},
 		},
+		Usage: Usage{
+			PromptTokens:     5,
+			CompletionTokens: 19,
+			TotalTokens:      24,
+		},
 	}
 
 	if diff := cmp.Diff(expected, res); diff != "" {
@@ -128,3 +139,208 @@ func TestChat_Fail(t *testing.T) {
 		t.Fatalf("expected error to contain 'status code: 404', got %s", err.Error())
 	}
 }
+
+func TestCompatibleClient_NoAPIKey(t *testing.T) {
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	param := &Payload{
+		Model: "llama3",
+		Messages: []Message{
+			{
+				Role:    "user",
+				Content: "Tell me a joke.",
+			},
+		},
+	}
+
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if auth := r.Header.Get("Authorization"); auth != "" {
+			t.Fatalf("Authorization expected to be empty, got %s", auth)
+		}
+		http.ServeFile(w, r, "testdata/gemini_success.json")
+	})
+
+	client, err := NewCompatibleClient(server.URL, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := client.Chat(context.Background(), param)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(res.Choices) != 1 {
+		t.Fatalf("expected 1 choice, got %d", len(res.Choices))
+	}
+}
+
+func TestChat_FailProvider(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		fmt.Fprint(w, `{"error":{"message":"Incorrect API key provided","code":"invalid_api_key"}}`)
+	}))
+	defer server.Close()
+
+	param := &Payload{Model: "llama3", Messages: []Message{{Role: "user", Content: "Tell me a joke."}}}
+	gc, err := NewClient(server.URL, "test-token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cc, err := NewCompatibleClient(server.URL, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for provider, client := range map[string]*Client{"gemini": gc, "openai-compatible": cc} {
+		_, err := client.Chat(context.Background(), param)
+		var apiErr *apierror.APIError
+		if !errors.As(err, &apiErr) {
+			t.Fatalf("%s: expected an API error, got %v", provider, err)
+		}
+		if apiErr.Provider != provider {
+			t.Errorf("Provider=%q, want %q", apiErr.Provider, provider)
+		}
+	}
+}
+
+func TestChatStream(t *testing.T) {
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	param := &Payload{
+		Model: "gemini-2.0-flash-lite",
+		Messages: []Message{
+			{
+				Role:    "user",
+				Content: "Tell me a joke.",
+			},
+		},
+	}
+
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		actualPayload := &Payload{}
+		if err := json.NewDecoder(r.Body).Decode(actualPayload); err != nil {
+			t.Fatal(err)
+		}
+		if !actualPayload.Stream {
+			t.Fatal("expected stream to be true")
+		}
+		if actualPayload.StreamOptions == nil || !actualPayload.StreamOptions.IncludeUsage {
+			t.Fatal("expected stream_options.include_usage to be true")
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		chunks := []string{
+			`{"choices":[{"index":0,"delta":{"role":"assistant","content":""}}]}`,
+			`{"choices":[{"index":0,"delta":{"content":"Why did"}}]}`,
+			`{"choices":[{"index":0,"delta":{"content":" the chicken"}}]}`,
+			`{"choices":[{"index":0,"delta":{},"finish_reason":"stop"}]}`,
+			`{"choices":[],"usage":{"prompt_tokens":4,"completion_tokens":3,"total_tokens":7}}`,
+			`[DONE]`,
+		}
+		for _, data := range chunks {
+			fmt.Fprintf(w, "data: %s\n\n", data)
+			w.(http.Flusher).Flush()
+		}
+	})
+
+	client, err := NewClient(server.URL, "test-token")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var b strings.Builder
+	usage, err := client.ChatStream(context.Background(), param, func(delta string) error {
+		b.WriteString(delta)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if b.String() != "Why did the chicken" {
+		t.Errorf("expected %q, got %q", "Why did the chicken", b.String())
+	}
+
+	expectedUsage := &Usage{PromptTokens: 4, CompletionTokens: 3, TotalTokens: 7}
+	if diff := cmp.Diff(expectedUsage, usage); diff != "" {
+		t.Errorf("usage mismatch (-expected +actual):\n%s", diff)
+	}
+}
+
+func TestChat_Retry(t *testing.T) {
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	param := &Payload{
+		Model: "gemini-2.0-flash-lite",
+		Messages: []Message{
+			{
+				Role:    "user",
+				Content: "Tell me a joke.",
+			},
+		},
+	}
+
+	attempts := 0
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		http.ServeFile(w, r, "testdata/gemini_success.json")
+	})
+
+	client, err := NewClient(server.URL, "test-token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.Retry = &retry.Policy{MaxRetries: 2, BaseDelay: time.Millisecond, MaxDelay: time.Second}
+
+	res, err := client.Chat(context.Background(), param)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if attempts != 2 {
+		t.Errorf("expected 2 attempts, got %d", attempts)
+	}
+	if len(res.Choices) != 1 {
+		t.Fatalf("expected 1 choice, got %d", len(res.Choices))
+	}
+}
+
+func TestChatStream_Incomplete(t *testing.T) {
+	param := &Payload{Model: "llama3", Messages: []Message{{Role: "user", Content: "Tell me a joke."}}}
+	tests := map[string]string{
+		// The connection is dropped before [DONE].
+		"dropped": "data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":\"Hel\"}}]}\n\n",
+		// Some servers end the stream after the finish reason without [DONE].
+		"finished": "data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":\"Hello\"},\"finish_reason\":\"stop\"}]}\n\n",
+	}
+	for name, body := range tests {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/event-stream")
+			fmt.Fprint(w, body)
+		}))
+		defer server.Close()
+
+		client, err := NewCompatibleClient(server.URL, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = client.ChatStream(t.Context(), param, func(delta string) error {
+			return nil
+		})
+		if got, want := errors.Is(err, sse.ErrIncomplete), name == "dropped"; got != want {
+			t.Errorf("%s: unexpected error %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,262 @@
+// Package markdown splits Markdown documents into the text to translate and the
+// parts to keep unchanged, such as front matter, code blocks, HTML and link targets.
+package markdown
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/catatsuy/bento/internal/placeholder"
+)
+
+// Segment is a part of a Markdown document.
+type Segment struct {
+	Text string
+	// Translatable is false for the parts that must be kept unchanged.
+	Translatable bool
+}
+
+var (
+	// linkDefinition matches a link reference definition such as "[id]: https://example.com".
+	linkDefinition = regexp.MustCompile(`^ {0,3}\[[^\]]+\]:\s`)
+	// listItem matches the marker of a list item.
+	listItem = regexp.MustCompile(`^ {0,3}(?:[-+*]|\d{1,9}[.)])(?:\s|$)`)
+)
+
+// Split splits src into segments. Front matter, fenced and indented code blocks,
+// HTML blocks and link reference definitions are not translatable.
+// Concatenating the texts of the segments gives src.
+func Split(src string) []Segment {
+	var segs []Segment
+	add := func(text string, translatable bool) {
+		n := len(segs)
+		// White space belongs to the preceding segment, so that paragraphs are sent together.
+		if strings.TrimSpace(text) == "" {
+			if n > 0 {
+				segs[n-1].Text += text
+				return
+			}
+			translatable = false
+		}
+		if n > 0 && segs[n-1].Translatable == translatable {
+			segs[n-1].Text += text
+			return
+		}
+		segs = append(segs, Segment{Text: text, Translatable: translatable})
+	}
+
+	lines := SplitLines(src)
+	i := frontMatterEnd(lines)
+	if i > 0 {
+		add(strings.Join(lines[:i], ""), false)
+	}
+
+	var fence string
+	prevBlank := true
+	inList, inCode, inHTML := false, false, false
+	for ; i < len(lines); i++ {
+		line := lines[i]
+		blank := strings.TrimSpace(line) == ""
+
+		switch {
+		case fence != "":
+			add(line, false)
+			if IsFenceEnd(line, fence) {
+				fence = ""
+			}
+		case blank:
+			add(line, false)
+			inHTML = false
+		case inHTML:
+			add(line, false)
+		case inCode && isIndented(line):
+			add(line, false)
+		case FenceStart(line) != "":
+			fence = FenceStart(line)
+			add(line, false)
+		case prevBlank && !inList && isIndented(line):
+			inCode = true
+			add(line, false)
+		case prevBlank && isHTMLStart(line):
+			inHTML = true
+			add(line, false)
+		case linkDefinition.MatchString(line):
+			add(line, false)
+		default:
+			if listItem.MatchString(line) {
+				inList = true
+			} else if prevBlank && !isIndented(line) {
+				inList = false
+			}
+			add(line, true)
+		}
+
+		if !blank && !isIndented(line) {
+			inCode = false
+		}
+		prevBlank = blank
+	}
+
+	return segs
+}
+
+// frontMatterEnd returns the number of lines of the YAML or TOML front matter at the start of lines.
+func frontMatterEnd(lines []string) int {
+	if len(lines) == 0 {
+		return 0
+	}
+	delim := strings.TrimRight(lines[0], " \r\n")
+	if delim != "---" && delim != "+++" {
+		return 0
+	}
+	for i := 1; i < len(lines); i++ {
+		l := strings.TrimRight(lines[i], " \r\n")
+		if l == delim || (delim == "---" && l == "...") {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// isIndented reports whether line is indented by four spaces or a tab.
+func isIndented(line string) bool {
+	return strings.HasPrefix(line, "    ") || strings.HasPrefix(line, "\t")
+}
+
+// isHTMLStart reports whether line starts an HTML block.
+func isHTMLStart(line string) bool {
+	s := strings.TrimLeft(line, " ")
+	if len(line)-len(s) > 3 || len(s) < 2 || s[0] != '<' {
+		return false
+	}
+	c := s[1]
+	return c == '/' || c == '!' || c == '?' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
+
+// SplitLines splits text after each newline.
+func SplitLines(text string) []string {
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// IsHeading reports whether line is an ATX heading.
+func IsHeading(line string) bool {
+	s := strings.TrimLeft(line, " ")
+	if len(line)-len(s) > 3 {
+		return false
+	}
+	n := len(s) - len(strings.TrimLeft(s, "#"))
+	if n == 0 || n > 6 {
+		return false
+	}
+	rest := s[n:]
+	return rest == "" || rest[0] == ' ' || rest[0] == '\t' || rest[0] == '\n' || rest[0] == '\r'
+}
+
+// FenceStart returns the opening fence of a fenced code block, or an empty string if line does not open one.
+func FenceStart(line string) string {
+	s := strings.TrimLeft(line, " ")
+	if len(line)-len(s) > 3 {
+		return ""
+	}
+	for _, c := range []string{"`", "~"} {
+		n := len(s) - len(strings.TrimLeft(s, c))
+		if n < 3 {
+			continue
+		}
+		// The info string of a backtick fence cannot contain backticks.
+		if c == "`" && strings.Contains(s[n:], "`") {
+			return ""
+		}
+		return s[:n]
+	}
+	return ""
+}
+
+// IsFenceEnd reports whether line closes the fenced code block opened with fence.
+func IsFenceEnd(line, fence string) bool {
+	s := strings.TrimSpace(line)
+	return len(s) >= len(fence) && strings.Trim(s, fence[:1]) == ""
+}
+
+// inlinePattern matches the inline elements to keep unchanged outside code spans:
+// link destinations, reference labels, HTML comments and tags, autolinks and bare URLs.
+var inlinePattern = regexp.MustCompile(`\]\([^)\n]*\)` +
+	`|\]\[[^\]\n]*\]` +
+	`|<!--[\s\S]*?-->` +
+	`|</?[A-Za-z][A-Za-z0-9-]*(?:\s[^<>]*)?/?>` +
+	`|<[A-Za-z][A-Za-z0-9+.-]*:[^<>\s]*>` +
+	`|https?://[^\s<>()\[\]]*[^\s<>()\[\].,:;!?'"]`)
+
+// Protect replaces code spans, link destinations, reference labels, HTML and URLs
+// in text with placeholders such as ⟦0⟧. It returns the replaced text and the
+// original values in the order of the placeholders.
+func Protect(text string) (string, []string) {
+	var b strings.Builder
+	var protected []string
+	keep := func(s string) {
+		b.WriteString(placeholder.Placeholder(len(protected)))
+		protected = append(protected, s)
+	}
+	protectInline := func(s string) {
+		last := 0
+		for _, loc := range inlinePattern.FindAllStringIndex(s, -1) {
+			b.WriteString(s[last:loc[0]])
+			m := s[loc[0]:loc[1]]
+			// The bracket of a link is part of the text.
+			if strings.HasPrefix(m, "]") {
+				b.WriteString("]")
+				m = m[1:]
+			}
+			keep(m)
+			last = loc[1]
+		}
+		b.WriteString(s[last:])
+	}
+
+	for text != "" {
+		start, end := codeSpan(text)
+		if start < 0 {
+			protectInline(text)
+			break
+		}
+		protectInline(text[:start])
+		keep(text[start:end])
+		text = text[end:]
+	}
+	return b.String(), protected
+}
+
+// codeSpan returns the position of the first code span in s, or -1 if there is none.
+func codeSpan(s string) (int, int) {
+	offset := 0
+	for {
+		i := strings.IndexByte(s[offset:], '`')
+		if i < 0 {
+			return -1, -1
+		}
+		start := offset + i
+		n := len(s[start:]) - len(strings.TrimLeft(s[start:], "`"))
+		opener := s[start : start+n]
+
+		// The closing backtick string must have the same length as the opening one.
+		rest := start + n
+		for {
+			j := strings.Index(s[rest:], opener)
+			if j < 0 {
+				break
+			}
+			closeStart := rest + j
+			closeEnd := closeStart + n
+			if closeEnd < len(s) && s[closeEnd] == '`' {
+				rest = closeEnd + len(s[closeEnd:]) - len(strings.TrimLeft(s[closeEnd:], "`"))
+				continue
+			}
+			return start, closeEnd
+		}
+		offset = start + n
+	}
+}
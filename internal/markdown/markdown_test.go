@@ -0,0 +1,131 @@
+package markdown_test
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	. "github.com/catatsuy/bento/internal/markdown"
+	"github.com/catatsuy/bento/internal/placeholder"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSplit(t *testing.T) {
+	b, err := os.ReadFile("testdata/document.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	src := string(b)
+
+	segs := Split(src)
+
+	var all, translatable strings.Builder
+	for _, seg := range segs {
+		all.WriteString(seg.Text)
+		if seg.Translatable {
+			translatable.WriteString(seg.Text)
+		}
+	}
+	if all.String() != src {
+		t.Fatalf("segments do not add up to the document:\n%s", all.String())
+	}
+
+	for _, s := range []string{
+		"# Getting Started\n",
+		"Install the tool",
+		"- First item",
+		"  continued line\n",
+		"    indented list continuation\n",
+		"| foo  |",
+		"See [the guide][guide]",
+	} {
+		if !strings.Contains(translatable.String(), s) {
+			t.Errorf("expected %q to be translatable", s)
+		}
+	}
+
+	for _, s := range []string{
+		"title: Getting Started",
+		"# comment that must not be translated",
+		"<div align=\"center\">",
+		"<img src=\"logo.png\">",
+		"    indented code block\n",
+		"[guide]: https://example.com/guide",
+	} {
+		if strings.Contains(translatable.String(), s) {
+			t.Errorf("expected %q not to be translatable", s)
+		}
+	}
+}
+
+func TestSplit_blocks(t *testing.T) {
+	src := "\nText\n\n---\n\n```\ncode\n\n```\n\nMore text\n"
+	expected := []Segment{
+		{Text: "\n", Translatable: false},
+		{Text: "Text\n\n---\n\n", Translatable: true},
+		{Text: "```\ncode\n\n```\n\n", Translatable: false},
+		{Text: "More text\n", Translatable: true},
+	}
+	if diff := cmp.Diff(expected, Split(src)); diff != "" {
+		t.Errorf("segments mismatch (-expected +actual):\n%s", diff)
+	}
+}
+
+func TestProtect(t *testing.T) {
+	tests := []struct {
+		text      string
+		expected  string
+		protected []string
+	}{
+		{
+			text:      "Run `go test` now.",
+			expected:  "Run ⟦0⟧ now.",
+			protected: []string{"`go test`"},
+		},
+		{
+			text:      "Use ``a `b` c`` here",
+			expected:  "Use ⟦0⟧ here",
+			protected: []string{"``a `b` c``"},
+		},
+		{
+			text:      "Read the [docs](https://example.com/docs \"Docs\") and ![logo](logo.png).",
+			expected:  "Read the [docs]⟦0⟧ and ![logo]⟦1⟧.",
+			protected: []string{"(https://example.com/docs \"Docs\")", "(logo.png)"},
+		},
+		{
+			text:      "See [the guide][guide] or <https://example.com>.",
+			expected:  "See [the guide]⟦0⟧ or ⟦1⟧.",
+			protected: []string{"[guide]", "<https://example.com>"},
+		},
+		{
+			text:      "Press <kbd>Ctrl</kbd> <!-- note --> at https://example.com/faq.",
+			expected:  "Press ⟦0⟧Ctrl⟦1⟧ ⟦2⟧ at ⟦3⟧.",
+			protected: []string{"<kbd>", "</kbd>", "<!-- note -->", "https://example.com/faq"},
+		},
+		{
+			text:      "An unclosed ` backtick and a < b",
+			expected:  "An unclosed ` backtick and a < b",
+			protected: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			text, protected := Protect(tt.text)
+			if text != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, text)
+			}
+			if diff := cmp.Diff(tt.protected, protected); diff != "" {
+				t.Errorf("protected mismatch (-expected +actual):\n%s", diff)
+			}
+
+			restored, err := placeholder.Restore(text, protected)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if restored != tt.text {
+				t.Errorf("expected the round trip to give %q, got %q", tt.text, restored)
+			}
+		})
+	}
+}
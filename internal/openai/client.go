@@ -1,14 +1,16 @@
 package openai
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
-	"time"
+
+	"github.com/catatsuy/bento/internal/apiclient"
+	"github.com/catatsuy/bento/internal/apierror"
+	"github.com/catatsuy/bento/internal/sse"
 )
 
 var (
@@ -16,15 +18,15 @@ var (
 )
 
 type Client struct {
-	URL        *url.URL
-	HTTPClient *http.Client
-	APIKey     string
+	apiclient.Client
+	APIKey string
 }
 
 type Payload struct {
 	Model        string `json:"model"`
 	Input        string `json:"input,omitempty"` // Can also be an array of Message objects
 	Instructions string `json:"instructions,omitempty"`
+	Stream       bool   `json:"stream,omitempty"`
 }
 
 type Message struct {
@@ -51,10 +53,11 @@ type Content struct {
 	Annotations []string `json:"annotations"`
 }
 
+// Usage reports the number of tokens used by the request.
 type Usage struct {
-	PromptTokens     int `json:"prompt_tokens"`
-	CompletionTokens int `json:"completion_tokens"`
-	TotalTokens      int `json:"total_tokens"`
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+	TotalTokens  int `json:"total_tokens"`
 }
 
 // OutputText returns the first text content from the response
@@ -86,61 +89,102 @@ func NewClient(urlStr, apiKey string) (*Client, error) {
 	}
 
 	client := &Client{
-		URL:        parsedURL,
-		HTTPClient: &http.Client{Timeout: 30 * time.Second},
-		APIKey:     apiKey,
+		Client: apiclient.New(parsedURL, "openai"),
+		APIKey: apiKey,
 	}
 
 	return client, nil
 }
 
-func (c *Client) newRequest(ctx context.Context, method string, body io.Reader) (*http.Request, error) {
-	u := *c.URL
+// header returns the headers authenticating a request.
+func (c *Client) header() http.Header {
+	h := http.Header{}
+	h.Set("Authorization", "Bearer "+c.APIKey)
+	return h
+}
 
-	req, err := http.NewRequest(method, u.String(), body)
+func (c *Client) Chat(ctx context.Context, param *Payload) (*Response, error) {
+	if param.Input == "" {
+		return nil, nil
+	}
+
+	res, err := c.Post(ctx, c.header(), param)
 	if err != nil {
 		return nil, err
 	}
+	defer res.Body.Close()
+
+	response := &Response{}
+	err = json.NewDecoder(res.Body).Decode(response)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode response body: %w", err)
+	}
 
-	req = req.WithContext(ctx)
+	return response, nil
+}
 
-	return req, nil
+// StreamEvent is an event of the Responses API stream.
+type StreamEvent struct {
+	Type     string    `json:"type"`
+	Delta    string    `json:"delta"`
+	Response *Response `json:"response"`
+	Message  string    `json:"message"`
 }
 
-func (c *Client) Chat(ctx context.Context, param *Payload) (*Response, error) {
+// ChatStream sends a streaming request and calls fn with each text delta as it arrives.
+// It returns the usage reported at the end of the stream, or nil if none was reported.
+func (c *Client) ChatStream(ctx context.Context, param *Payload, fn func(delta string) error) (*Usage, error) {
 	if param.Input == "" {
 		return nil, nil
 	}
 
-	b, _ := json.Marshal(param)
-
-	req, err := c.newRequest(ctx, http.MethodPost, bytes.NewBuffer(b))
-	if err != nil {
-		return nil, err
-	}
-
-	req.Header.Set("Authorization", "Bearer "+c.APIKey)
-	req.Header.Set("Content-Type", "application/json")
+	p := *param
+	p.Stream = true
 
-	res, err := c.HTTPClient.Do(req)
+	res, err := c.PostStream(ctx, c.header(), &p)
 	if err != nil {
 		return nil, err
 	}
 	defer res.Body.Close()
 
-	if res.StatusCode != http.StatusOK {
-		b, err := io.ReadAll(res.Body)
+	reader := sse.NewReader(res.Body)
+	for {
+		ev, err := reader.Next()
+		if err == io.EOF {
+			// The response ends with response.completed, so the output is truncated.
+			return nil, sse.ErrIncomplete
+		}
 		if err != nil {
-			return nil, fmt.Errorf("failed to read res.Body and the status code of the response from slack was not 200: %w", err)
+			return nil, fmt.Errorf("failed to read stream: %w", err)
 		}
-		return nil, fmt.Errorf("status code: %d; body: %s", res.StatusCode, b)
-	}
 
-	response := &Response{}
-	err = json.NewDecoder(res.Body).Decode(response)
-	if err != nil {
-		return nil, fmt.Errorf("failed to decode response body: %w", err)
-	}
+		streamEvent := &StreamEvent{}
+		if err := json.Unmarshal([]byte(ev.Data), streamEvent); err != nil {
+			return nil, fmt.Errorf("failed to decode stream event: %w", err)
+		}
 
-	return response, nil
+		switch streamEvent.Type {
+		case "response.output_text.delta":
+			if err := fn(streamEvent.Delta); err != nil {
+				return nil, err
+			}
+		case "response.completed":
+			if streamEvent.Response == nil {
+				return nil, nil
+			}
+			return &streamEvent.Response.Usage, nil
+		case "error":
+			return nil, fmt.Errorf("stream error: %w", apierror.Parse("openai", []byte(ev.Data)))
+		case "response.failed":
+			failed := struct {
+				Response struct {
+					Error json.RawMessage `json:"error"`
+				} `json:"response"`
+			}{}
+			if err := json.Unmarshal([]byte(ev.Data), &failed); err != nil || len(failed.Response.Error) == 0 {
+				return nil, fmt.Errorf("stream error: %s", ev.Data)
+			}
+			return nil, fmt.Errorf("stream error: %w", apierror.Parse("openai", failed.Response.Error))
+		}
+	}
 }
@@ -2,14 +2,20 @@ package openai_test
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/catatsuy/bento/internal/apierror"
 	. "github.com/catatsuy/bento/internal/openai"
+	"github.com/catatsuy/bento/internal/retry"
+	"github.com/catatsuy/bento/internal/sse"
 	"github.com/google/go-cmp/cmp"
 )
 
@@ -51,7 +57,7 @@ func TestPostText_Success(t *testing.T) {
 		}
 
 		if !reflect.DeepEqual(actualBody, param) {
-			t.Fatalf("expected %q to equal %q", actualBody, param)
+			t.Fatalf("expected %+v to equal %+v", actualBody, param)
 		}
 
 		http.ServeFile(w, r, "testdata/chat_ok.json")
@@ -84,9 +90,9 @@ func TestPostText_Success(t *testing.T) {
 			},
 		},
 		Usage: Usage{
-			PromptTokens:     9,
-			CompletionTokens: 12,
-			TotalTokens:      21,
+			InputTokens:  9,
+			OutputTokens: 12,
+			TotalTokens:  21,
 		},
 	}
 
@@ -125,4 +131,140 @@ func TestPostText_Fail(t *testing.T) {
 	if !strings.Contains(err.Error(), expected) {
 		t.Fatalf("expected %q to contain %q", err.Error(), expected)
 	}
+
+	var apiErr *apierror.APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("expected *apierror.APIError, got %T", err)
+	}
+	if apiErr.Code != "model_not_found" || apiErr.Type != "invalid_request_error" {
+		t.Errorf("unexpected error code or type: %+v", apiErr)
+	}
+	if apiErr.Category() != apierror.CategoryNotFound {
+		t.Errorf("Category()=%v, want %v", apiErr.Category(), apierror.CategoryNotFound)
+	}
+}
+
+func TestChatStream(t *testing.T) {
+	muxAPI := http.NewServeMux()
+	testAPIServer := httptest.NewServer(muxAPI)
+	defer testAPIServer.Close()
+
+	param := &Payload{
+		Model: "gpt-5-nano",
+		Input: "Hello. I am a student.",
+	}
+
+	muxAPI.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		actualBody := &Payload{}
+		if err := json.NewDecoder(r.Body).Decode(actualBody); err != nil {
+			t.Fatal(err)
+		}
+		if !actualBody.Stream {
+			t.Fatal("expected stream to be true")
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		events := []string{
+			`{"type":"response.created"}`,
+			`{"type":"response.output_text.delta","delta":"Hello"}`,
+			`{"type":"response.output_text.delta","delta":" there"}`,
+			`{"type":"response.completed","response":{"id":"resp_1","usage":{"input_tokens":5,"output_tokens":2,"total_tokens":7}}}`,
+		}
+		for _, data := range events {
+			fmt.Fprintf(w, "event: x\ndata: %s\n\n", data)
+			w.(http.Flusher).Flush()
+		}
+	})
+
+	c, err := NewClient(testAPIServer.URL, "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var b strings.Builder
+	usage, err := c.ChatStream(t.Context(), param, func(delta string) error {
+		b.WriteString(delta)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if b.String() != "Hello there" {
+		t.Errorf("expected %q, got %q", "Hello there", b.String())
+	}
+
+	expectedUsage := &Usage{InputTokens: 5, OutputTokens: 2, TotalTokens: 7}
+	if diff := cmp.Diff(expectedUsage, usage); diff != "" {
+		t.Errorf("usage mismatch (-expected +actual):\n%s", diff)
+	}
+}
+
+func TestPostText_Retry(t *testing.T) {
+	muxAPI := http.NewServeMux()
+	testAPIServer := httptest.NewServer(muxAPI)
+	defer testAPIServer.Close()
+
+	param := &Payload{
+		Model: "gpt-5-nano",
+		Input: "Hello. I am a student.",
+	}
+
+	attempts := 0
+	muxAPI.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		actualBody := &Payload{}
+		if err := json.NewDecoder(r.Body).Decode(actualBody); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(actualBody, param) {
+			t.Fatalf("expected %+v to equal %+v", actualBody, param)
+		}
+
+		if attempts == 1 {
+			w.Header().Set("x-ratelimit-reset-requests", "10ms")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		http.ServeFile(w, r, "testdata/chat_ok.json")
+	})
+
+	c, err := NewClient(testAPIServer.URL, "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Retry = &retry.Policy{MaxRetries: 2, BaseDelay: time.Millisecond, MaxDelay: time.Second}
+
+	res, err := c.Chat(t.Context(), param)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if attempts != 2 {
+		t.Errorf("expected 2 attempts, got %d", attempts)
+	}
+
+	if res.OutputText() != "\n\nHello there, how may I assist you today?" {
+		t.Errorf("unexpected output text: %q", res.OutputText())
+	}
+}
+
+func TestChatStream_Incomplete(t *testing.T) {
+	// The connection is dropped before response.completed.
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: {\"type\":\"response.output_text.delta\",\"delta\":\"Hel\"}\n\n")
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL, "test-token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = client.ChatStream(t.Context(), &Payload{Model: "gpt-5-nano", Input: "Hello."}, func(delta string) error {
+		return nil
+	})
+	if !errors.Is(err, sse.ErrIncomplete) {
+		t.Errorf("expected %v, got %v", sse.ErrIncomplete, err)
+	}
 }
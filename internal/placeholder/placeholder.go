@@ -0,0 +1,54 @@
+// Package placeholder replaces the parts of a text that a model must not change,
+// such as code or format verbs, with placeholders like ⟦0⟧ and restores them in the response.
+package placeholder
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+// pattern matches the placeholders.
+var pattern = regexp.MustCompile(`⟦(\d+)⟧`)
+
+// Placeholder returns the i-th placeholder.
+func Placeholder(i int) string {
+	return fmt.Sprintf("⟦%d⟧", i)
+}
+
+// Protect replaces the matches of re in text with placeholders. It returns the
+// replaced text and the original values in the order of the placeholders.
+func Protect(text string, re *regexp.Regexp) (string, []string) {
+	var protected []string
+	replaced := re.ReplaceAllStringFunc(text, func(m string) string {
+		protected = append(protected, m)
+		return Placeholder(len(protected) - 1)
+	})
+	return replaced, protected
+}
+
+// Restore replaces the placeholders in text with the protected values.
+// It returns an error if a placeholder is missing or unknown, which happens when
+// the model did not keep them.
+func Restore(text string, protected []string) (string, error) {
+	seen := make([]bool, len(protected))
+	var err error
+	restored := pattern.ReplaceAllStringFunc(text, func(m string) string {
+		i, _ := strconv.Atoi(pattern.FindStringSubmatch(m)[1])
+		if i >= len(protected) {
+			err = fmt.Errorf("unknown placeholder %s", m)
+			return m
+		}
+		seen[i] = true
+		return protected[i]
+	})
+	if err != nil {
+		return "", err
+	}
+	for i, ok := range seen {
+		if !ok {
+			return "", fmt.Errorf("placeholder %s is missing", Placeholder(i))
+		}
+	}
+	return restored, nil
+}
@@ -0,0 +1,53 @@
+package placeholder_test
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	. "github.com/catatsuy/bento/internal/placeholder"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestProtect(t *testing.T) {
+	re := regexp.MustCompile(`%[sd]|\{[a-z]+\}`)
+
+	text, protected := Protect("Hello {name}, you have %d messages", re)
+	if text != "Hello ⟦0⟧, you have ⟦1⟧ messages" {
+		t.Errorf("unexpected text: %q", text)
+	}
+	if diff := cmp.Diff([]string{"{name}", "%d"}, protected); diff != "" {
+		t.Errorf("protected mismatch (-expected +actual):\n%s", diff)
+	}
+
+	restored, err := Restore(text, protected)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restored != "Hello {name}, you have %d messages" {
+		t.Errorf("unexpected restored text: %q", restored)
+	}
+}
+
+func TestRestore_reordered(t *testing.T) {
+	restored, err := Restore("⟦1⟧ を実行して ⟦0⟧ を読む", []string{"[docs](a)", "`go test`"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "`go test` を実行して [docs](a) を読む"
+	if restored != expected {
+		t.Errorf("expected %q, got %q", expected, restored)
+	}
+}
+
+func TestRestore_fail(t *testing.T) {
+	_, err := Restore("⟦0⟧ only", []string{"a", "b"})
+	if err == nil || !strings.Contains(err.Error(), "⟦1⟧ is missing") {
+		t.Errorf("expected a missing placeholder error, got %v", err)
+	}
+
+	_, err = Restore("⟦0⟧ ⟦5⟧", []string{"a"})
+	if err == nil || !strings.Contains(err.Error(), "unknown placeholder ⟦5⟧") {
+		t.Errorf("expected an unknown placeholder error, got %v", err)
+	}
+}
@@ -0,0 +1,168 @@
+// Package retry implements the retry policy shared by the API clients.
+package retry
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultMaxRetries = 3
+	DefaultBaseDelay  = 1 * time.Second
+	DefaultMaxDelay   = 60 * time.Second
+)
+
+// Policy retries requests that failed with a transient error
+// using exponential backoff with jitter.
+// A nil *Policy sends the request only once.
+type Policy struct {
+	// MaxRetries is the number of retries after the first attempt.
+	MaxRetries int
+	// BaseDelay is the backoff delay before the first retry.
+	BaseDelay time.Duration
+	// MaxDelay caps the delay between attempts, including delays requested by the server.
+	MaxDelay time.Duration
+}
+
+// NewPolicy returns a Policy with the default delays.
+func NewPolicy(maxRetries int) *Policy {
+	return &Policy{
+		MaxRetries: maxRetries,
+		BaseDelay:  DefaultBaseDelay,
+		MaxDelay:   DefaultMaxDelay,
+	}
+}
+
+// Do calls send until it returns a response that should not be retried,
+// the retries are exhausted or ctx is done.
+// The response of the last attempt is returned as is.
+func (p *Policy) Do(ctx context.Context, send func() (*http.Response, error)) (*http.Response, error) {
+	if p == nil {
+		return send()
+	}
+
+	for attempt := 0; ; attempt++ {
+		res, err := send()
+		if attempt >= p.MaxRetries || !shouldRetry(ctx, res, err) {
+			return res, err
+		}
+
+		delay := p.backoff(attempt)
+		if res != nil {
+			if d, ok := RetryAfter(res.Header, time.Now()); ok {
+				delay = d
+			}
+			// Drain the body so that the connection can be reused.
+			_, _ = io.Copy(io.Discard, res.Body)
+			res.Body.Close()
+		}
+		if p.MaxDelay > 0 && delay > p.MaxDelay {
+			delay = p.MaxDelay
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// backoff returns the jittered delay before the retry after the given attempt.
+func (p *Policy) backoff(attempt int) time.Duration {
+	d := p.BaseDelay << min(attempt, 30)
+	if d <= 0 || (p.MaxDelay > 0 && d > p.MaxDelay) {
+		d = p.MaxDelay
+	}
+	if d <= 0 {
+		return 0
+	}
+	// Equal jitter: half of the delay is fixed and the other half is random.
+	half := d / 2
+	return half + rand.N(d-half+1)
+}
+
+// shouldRetry reports whether the result of an attempt is a transient failure.
+func shouldRetry(ctx context.Context, res *http.Response, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if err != nil {
+		return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+	}
+	switch res.StatusCode {
+	case http.StatusRequestTimeout,
+		http.StatusTooManyRequests,
+		http.StatusInternalServerError,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout,
+		529: // Anthropic returns 529 when the API is overloaded.
+		return true
+	}
+	return false
+}
+
+// RetryAfter returns the delay requested by the server through the
+// Retry-After, retry-after-ms or x-ratelimit-reset-* headers.
+// When several reset headers are present, the longest delay is used.
+func RetryAfter(h http.Header, now time.Time) (time.Duration, bool) {
+	if v := h.Get("retry-after-ms"); v != "" {
+		if ms, err := strconv.ParseFloat(v, 64); err == nil && ms >= 0 {
+			return time.Duration(ms * float64(time.Millisecond)), true
+		}
+	}
+
+	if v := h.Get("Retry-After"); v != "" {
+		if d, ok := parseDelay(v, now); ok {
+			return d, true
+		}
+	}
+
+	var (
+		delay time.Duration
+		found bool
+	)
+	for key, values := range h {
+		if !strings.HasPrefix(strings.ToLower(key), "x-ratelimit-reset-") || len(values) == 0 {
+			continue
+		}
+		if d, ok := parseDelay(values[0], now); ok {
+			found = true
+			delay = max(delay, d)
+		}
+	}
+	return delay, found
+}
+
+// parseDelay parses a delay given in seconds ("2", "0.5"),
+// as a duration ("6m0s", "20ms") or as a date (RFC 1123 or RFC 3339).
+func parseDelay(v string, now time.Time) (time.Duration, bool) {
+	v = strings.TrimSpace(v)
+	if secs, err := strconv.ParseFloat(v, 64); err == nil {
+		if secs < 0 {
+			return 0, false
+		}
+		return time.Duration(secs * float64(time.Second)), true
+	}
+	if d, err := time.ParseDuration(v); err == nil {
+		if d < 0 {
+			return 0, false
+		}
+		return d, true
+	}
+	for _, layout := range []string{http.TimeFormat, time.RFC3339} {
+		if t, err := time.Parse(layout, v); err == nil {
+			return max(t.Sub(now), 0), true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,78 @@
+// Package sse implements a minimal reader for server-sent events
+// as returned by the streaming endpoints of the supported APIs.
+package sse
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"strings"
+)
+
+// maxLineSize is the maximum size of a single line in the stream.
+const maxLineSize = 1024 * 1024
+
+// ErrIncomplete is returned by the streaming clients when the stream ends before the event
+// that marks the end of the response, such as when the connection is dropped.
+var ErrIncomplete = errors.New("stream ended before completion")
+
+// Event is a single server-sent event.
+type Event struct {
+	Event string
+	Data  string
+}
+
+// Reader reads events from a server-sent events stream.
+type Reader struct {
+	scanner *bufio.Scanner
+}
+
+// NewReader returns a new Reader reading from r.
+func NewReader(r io.Reader) *Reader {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+	return &Reader{scanner: scanner}
+}
+
+// Next returns the next event in the stream.
+// It returns io.EOF when the stream ends.
+func (r *Reader) Next() (*Event, error) {
+	var (
+		ev      Event
+		data    []string
+		hasData bool
+	)
+	for r.scanner.Scan() {
+		line := r.scanner.Text()
+		if line == "" {
+			// A blank line dispatches the event.
+			if hasData || ev.Event != "" {
+				ev.Data = strings.Join(data, "\n")
+				return &ev, nil
+			}
+			continue
+		}
+		if strings.HasPrefix(line, ":") {
+			// Comment line, often used as a keep-alive.
+			continue
+		}
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "event":
+			ev.Event = value
+		case "data":
+			data = append(data, value)
+			hasData = true
+		}
+	}
+	if err := r.scanner.Err(); err != nil {
+		return nil, err
+	}
+	// Dispatch the last event even if the stream does not end with a blank line.
+	if hasData || ev.Event != "" {
+		ev.Data = strings.Join(data, "\n")
+		return &ev, nil
+	}
+	return nil, io.EOF
+}
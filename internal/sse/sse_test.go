@@ -0,0 +1,43 @@
+package sse_test
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	. "github.com/catatsuy/bento/internal/sse"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestReader_Next(t *testing.T) {
+	stream := ": keep-alive\n\n" +
+		"event: response.output_text.delta\n" +
+		"data: {\"delta\":\"Hello\"}\n\n" +
+		"data: first\n" +
+		"data: second\n\n" +
+		"data: [DONE]"
+
+	r := NewReader(strings.NewReader(stream))
+
+	var actual []Event
+	for {
+		ev, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		actual = append(actual, *ev)
+	}
+
+	expected := []Event{
+		{Event: "response.output_text.delta", Data: `{"delta":"Hello"}`},
+		{Data: "first\nsecond"},
+		{Data: "[DONE]"},
+	}
+
+	if diff := cmp.Diff(expected, actual); diff != "" {
+		t.Errorf("mismatch (-expected +actual):\n%s", diff)
+	}
+}
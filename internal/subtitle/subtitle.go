@@ -0,0 +1,175 @@
+// Package subtitle parses SRT and WebVTT subtitle files into cues, so that the text of
+// the cues can be translated while the cue numbers, timestamps and settings are kept.
+package subtitle
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+)
+
+// Formats of the subtitle files.
+const (
+	FormatSRT = "srt"
+	FormatVTT = "vtt"
+)
+
+// Cue is a subtitle cue.
+type Cue struct {
+	// ID is the cue number of SRT files or the optional identifier of WebVTT cues.
+	ID string
+	// Timing is the line with the timestamps and, in WebVTT files, the cue settings.
+	Timing string
+	// Text is the text of the cue. Its lines are separated by "\n".
+	Text string
+	// Translation replaces Text when the file is written, unless it is empty.
+	Translation string
+}
+
+// part is a block of the file: a cue, or text written as it is, such as the WebVTT header,
+// NOTE, STYLE and REGION blocks and the blank lines between the blocks.
+type part struct {
+	raw string
+
+	cue *Cue
+	// header are the lines of the cue before its text, with their line endings.
+	header string
+	// newline is the line ending of the text, and end is the ending of its last line.
+	newline, end string
+}
+
+// File is a parsed subtitle file.
+type File struct {
+	// Format is FormatSRT or FormatVTT.
+	Format string
+
+	parts []part
+	cues  []*Cue
+}
+
+// Tags matches the markup of cue text that must not be translated: tags such as <i>, <v Name>
+// and <00:00:01.000>, and the SSA overrides such as {\an8} used in SRT files.
+var Tags = regexp.MustCompile(`<[^<>]*>|\{\\[^{}]*\}`)
+
+// Parse parses an SRT or WebVTT file. The format is WebVTT if src starts with "WEBVTT".
+func Parse(src string) (*File, error) {
+	f := &File{Format: FormatSRT}
+	if strings.HasPrefix(strings.TrimPrefix(src, "\ufeff"), "WEBVTT") {
+		f.Format = FormatVTT
+	}
+
+	var block []string
+	flush := func() {
+		if len(block) > 0 {
+			f.addBlock(block)
+			block = nil
+		}
+	}
+	for line := range strings.SplitAfterSeq(src, "\n") {
+		if line == "" {
+			continue
+		}
+		if strings.TrimSpace(line) == "" {
+			flush()
+			f.addRaw(line)
+			continue
+		}
+		block = append(block, line)
+	}
+	flush()
+
+	if len(f.cues) == 0 {
+		return nil, errors.New("no subtitle cues found")
+	}
+	return f, nil
+}
+
+// addBlock adds the lines of a block separated by blank lines.
+func (f *File) addBlock(lines []string) {
+	raw := strings.Join(lines, "")
+	if f.Format == FormatVTT && (len(f.parts) == 0 || isVTTMetadata(lines[0])) {
+		f.addRaw(raw)
+		return
+	}
+
+	// The timing line is the first line, or the second one after the cue number or identifier.
+	t := -1
+	for i := 0; i < len(lines) && i < 2; i++ {
+		if strings.Contains(lines[i], "-->") {
+			t = i
+			break
+		}
+	}
+	if t < 0 || t == len(lines)-1 {
+		f.addRaw(raw)
+		return
+	}
+
+	cue := &Cue{Timing: trimNewline(lines[t])}
+	if t == 1 {
+		cue.ID = trimNewline(lines[0])
+	}
+	text := make([]string, 0, len(lines)-t-1)
+	for _, line := range lines[t+1:] {
+		text = append(text, trimNewline(line))
+	}
+	cue.Text = strings.Join(text, "\n")
+
+	first, last := lines[t+1], lines[len(lines)-1]
+	f.parts = append(f.parts, part{
+		cue:     cue,
+		header:  strings.Join(lines[:t+1], ""),
+		newline: first[len(trimNewline(first)):],
+		end:     last[len(trimNewline(last)):],
+	})
+	f.cues = append(f.cues, cue)
+}
+
+func (f *File) addRaw(s string) {
+	f.parts = append(f.parts, part{raw: s})
+}
+
+// isVTTMetadata reports whether line starts a WebVTT block that is not a cue.
+func isVTTMetadata(line string) bool {
+	line = trimNewline(line)
+	for _, kw := range []string{"NOTE", "STYLE", "REGION"} {
+		if line == kw || strings.HasPrefix(line, kw+" ") || strings.HasPrefix(line, kw+"\t") {
+			return true
+		}
+	}
+	return false
+}
+
+func trimNewline(line string) string {
+	return strings.TrimRight(line, "\r\n")
+}
+
+// Cues returns the cues in the order of the file.
+func (f *File) Cues() []*Cue {
+	return f.cues
+}
+
+// String returns the file with the translations of the cues.
+// Blank lines in a translation are removed because they would end the cue.
+func (f *File) String() string {
+	var b strings.Builder
+	for _, p := range f.parts {
+		if p.cue == nil {
+			b.WriteString(p.raw)
+			continue
+		}
+		lines := strings.Split(p.cue.Text, "\n")
+		if p.cue.Translation != "" {
+			lines = lines[:0]
+			for line := range strings.SplitSeq(p.cue.Translation, "\n") {
+				if line = strings.TrimSpace(line); line != "" {
+					lines = append(lines, line)
+				}
+			}
+		}
+		b.WriteString(p.header)
+		b.WriteString(strings.Join(lines, p.newline))
+		b.WriteString(p.end)
+	}
+	return b.String()
+}
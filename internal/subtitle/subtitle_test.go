@@ -0,0 +1,114 @@
+package subtitle_test
+
+import (
+	"os"
+	"testing"
+
+	. "github.com/catatsuy/bento/internal/subtitle"
+	"github.com/google/go-cmp/cmp"
+)
+
+func parseFile(t *testing.T, name string) (*File, string) {
+	t.Helper()
+
+	b, err := os.ReadFile("testdata/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := Parse(string(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return f, string(b)
+}
+
+func cues(f *File) []Cue {
+	var cues []Cue
+	for _, cue := range f.Cues() {
+		cues = append(cues, *cue)
+	}
+	return cues
+}
+
+func TestParse_srt(t *testing.T) {
+	f, src := parseFile(t, "sample.srt")
+
+	if f.Format != FormatSRT {
+		t.Errorf("expected %s, got %s", FormatSRT, f.Format)
+	}
+	expected := []Cue{
+		{ID: "1", Timing: "00:00:01,000 --> 00:00:03,500", Text: "<i>Hello, world!</i>"},
+		{ID: "2", Timing: "00:00:04,000 --> 00:00:06,000", Text: "{\\an8}Where are you going?\nTo the station."},
+		{ID: "3", Timing: "00:00:07,000 --> 00:00:08,000", Text: "Goodbye."},
+	}
+	if diff := cmp.Diff(expected, cues(f)); diff != "" {
+		t.Errorf("cues mismatch (-expected +actual):\n%s", diff)
+	}
+
+	if f.String() != src {
+		t.Errorf("expected the file unchanged, got %q", f.String())
+	}
+
+	f.Cues()[1].Translation = "{\\an8}どこへ行くの？\n\n  駅まで。  \n"
+	f.Cues()[2].Translation = "さようなら。"
+	expectedSRT := "1\r\n00:00:01,000 --> 00:00:03,500\r\n<i>Hello, world!</i>\r\n\r\n" +
+		"2\r\n00:00:04,000 --> 00:00:06,000\r\n{\\an8}どこへ行くの？\r\n駅まで。\r\n\r\n" +
+		"3\r\n00:00:07,000 --> 00:00:08,000\r\nさようなら。\r\n"
+	if diff := cmp.Diff(expectedSRT, f.String()); diff != "" {
+		t.Errorf("SRT mismatch (-expected +actual):\n%s", diff)
+	}
+}
+
+func TestParse_vtt(t *testing.T) {
+	f, src := parseFile(t, "sample.vtt")
+
+	if f.Format != FormatVTT {
+		t.Errorf("expected %s, got %s", FormatVTT, f.Format)
+	}
+	// The header, NOTE and STYLE blocks are not cues.
+	expected := []Cue{
+		{ID: "intro", Timing: "00:00:01.000 --> 00:00:03.500 line:0 position:20%", Text: "<v Alice>Hello, world!"},
+		{Timing: "00:00:04.000 --> 00:00:06.000", Text: "Where are you going?\nTo the station."},
+	}
+	if diff := cmp.Diff(expected, cues(f)); diff != "" {
+		t.Errorf("cues mismatch (-expected +actual):\n%s", diff)
+	}
+
+	if f.String() != src {
+		t.Errorf("expected the file unchanged, got %q", f.String())
+	}
+
+	f.Cues()[0].Translation = "<v Alice>こんにちは、世界！"
+	expectedVTT := `WEBVTT - Sample
+
+NOTE This file is a sample.
+
+STYLE
+::cue { color: yellow; }
+
+intro
+00:00:01.000 --> 00:00:03.500 line:0 position:20%
+<v Alice>こんにちは、世界！
+
+00:00:04.000 --> 00:00:06.000
+Where are you going?
+To the station.
+`
+	if diff := cmp.Diff(expectedVTT, f.String()); diff != "" {
+		t.Errorf("WebVTT mismatch (-expected +actual):\n%s", diff)
+	}
+}
+
+func TestParse_noCues(t *testing.T) {
+	if _, err := Parse("Hello, world!\n"); err == nil {
+		t.Error("expected an error for a file without cues")
+	}
+}
+
+func TestTags(t *testing.T) {
+	got := Tags.FindAllString("{\\an8}<i>Hi</i> <00:00:01.000><c.yellow>there</c>", -1)
+	expected := []string{"{\\an8}", "<i>", "</i>", "<00:00:01.000>", "<c.yellow>", "</c>"}
+	if diff := cmp.Diff(expected, got); diff != "" {
+		t.Errorf("tags mismatch (-expected +actual):\n%s", diff)
+	}
+}